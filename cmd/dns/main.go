@@ -26,6 +26,7 @@ import (
 	"github.com/gardener/controller-manager-library/pkg/controllermanager/controller/mappings"
 
 	dnsprovider "github.com/gardener/external-dns-management/pkg/dns/provider"
+	"github.com/gardener/external-dns-management/pkg/dns/sharding"
 	dnssource "github.com/gardener/external-dns-management/pkg/dns/source"
 
 	_ "github.com/gardener/external-dns-management/pkg/controller/provider/googledns"
@@ -53,5 +54,11 @@ func main() {
 		fmt.Println(Version)
 		os.Exit(0)
 	}
-	controllermanager.Start("dns-controller-manager", "dns controller manager", "nothing")
+	// with sharding every shard requires its own leader election
+	shards, err := sharding.FromArgs(os.Args[1:])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s\n", err)
+		os.Exit(1)
+	}
+	controllermanager.Start(shards.Name("dns-controller-manager"), "dns controller manager", "nothing")
 }
@@ -0,0 +1,238 @@
+/*
+ * Copyright 2019 SAP SE or an SAP affiliate company. All rights reserved. This file is licensed under the Apache Software License, v. 2 except as noted otherwise in the LICENSE file
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ *
+ */
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/ghodss/yaml"
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+
+	"github.com/gardener/controller-manager-library/pkg/logger"
+	"github.com/gardener/controller-manager-library/pkg/utils"
+
+	api "github.com/gardener/external-dns-management/pkg/apis/dns/v1alpha1"
+	"github.com/gardener/external-dns-management/pkg/controller/provider/googledns"
+	"github.com/gardener/external-dns-management/pkg/controller/provider/route53"
+	"github.com/gardener/external-dns-management/pkg/dns"
+	"github.com/gardener/external-dns-management/pkg/dns/provider"
+)
+
+var factories = []provider.DNSHandlerFactory{
+	&route53.Factory{},
+	&googledns.Factory{},
+}
+
+type importOptions struct {
+	zone        string
+	names       string
+	types       []string
+	target      string
+	ttl         bool
+	skipManaged bool
+}
+
+func newImportCommand(opts *options) *cobra.Command {
+	iopts := &importOptions{}
+	cmd := &cobra.Command{
+		Use:   "import <provider>",
+		Short: "generate DNSEntry manifests for the records found in the hosted zones of a provider",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(_ *cobra.Command, args []string) error {
+			return runImport(opts, iopts, args[0])
+		},
+	}
+	flags := cmd.Flags()
+	flags.StringVar(&iopts.zone, "zone", "", "import only the hosted zone with this id")
+	flags.StringVar(&iopts.names, "names", "", "import only dns names matching this regular expression")
+	flags.StringSliceVar(&iopts.types, "types", []string{dns.RS_A, dns.RS_AAAA, dns.RS_CNAME, dns.RS_TXT}, "record types to import")
+	flags.StringVar(&iopts.target, "target-namespace", "", "namespace for the generated entries (default: namespace of provider)")
+	flags.BoolVar(&iopts.ttl, "ttl", true, "keep the ttl of the record sets")
+	flags.BoolVar(&iopts.skipManaged, "skip-managed", true, "skip record sets already owned by a dns controller")
+	return cmd
+}
+
+func runImport(opts *options, iopts *importOptions, name string) error {
+	var filter *regexp.Regexp
+	if iopts.names != "" {
+		var err error
+		filter, err = regexp.Compile(iopts.names)
+		if err != nil {
+			return fmt.Errorf("invalid name filter: %s", err)
+		}
+	}
+	types := utils.NewStringSetByArray(iopts.types)
+
+	cfg, namespace, err := opts.config()
+	if err != nil {
+		return err
+	}
+	cs, _, err := opts.clientset()
+	if err != nil {
+		return err
+	}
+	p, err := cs.KracV1alpha1().DNSProviders(namespace).Get(name, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("cannot get dns provider %s/%s: %s", namespace, name, err)
+	}
+	handler, err := createHandler(cfg, p)
+	if err != nil {
+		return err
+	}
+
+	target := iopts.target
+	if target == "" {
+		target = p.Namespace
+	}
+	zones, err := handler.GetZones()
+	if err != nil {
+		return fmt.Errorf("cannot get zones: %s", err)
+	}
+	found := false
+	for _, z := range zones {
+		if iopts.zone != "" && iopts.zone != z.Id {
+			continue
+		}
+		found = true
+		sets, err := handler.GetDNSSets(z.Id)
+		if err != nil {
+			return fmt.Errorf("cannot get record sets for zone %s: %s", z.Id, err)
+		}
+		names := []string{}
+		for n := range sets {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+		fmt.Fprintf(os.Stderr, "zone %s (%s): %d dns names\n", z.Id, z.Domain, len(names))
+		for _, n := range names {
+			if filter != nil && !filter.MatchString(n) {
+				continue
+			}
+			set := sets[n]
+			if iopts.skipManaged && set.GetOwner() != "" {
+				fmt.Fprintf(os.Stderr, "skipping %s: managed by %q\n", n, set.GetOwner())
+				continue
+			}
+			entry := newImportedEntry(target, p.Spec.Type, set, types, iopts.ttl)
+			if entry == nil {
+				continue
+			}
+			data, err := yaml.Marshal(entry)
+			if err != nil {
+				return err
+			}
+			fmt.Printf("---\n%s", data)
+		}
+	}
+	if iopts.zone != "" && !found {
+		return fmt.Errorf("hosted zone %q not found for provider %s/%s", iopts.zone, namespace, name)
+	}
+	return nil
+}
+
+func createHandler(cfg *rest.Config, p *api.DNSProvider) (provider.DNSHandler, error) {
+	var factory provider.DNSHandlerFactory
+	for _, f := range factories {
+		if f.TypeCode() == p.Spec.Type {
+			factory = f
+		}
+	}
+	if factory == nil {
+		return nil, fmt.Errorf("unsupported provider type %q", p.Spec.Type)
+	}
+	if p.Spec.SecretRef == nil {
+		return nil, fmt.Errorf("no secret specified for provider")
+	}
+	ns := p.Spec.SecretRef.Namespace
+	if ns == "" {
+		ns = p.Namespace
+	}
+	kube, err := kubernetes.NewForConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+	secret, err := kube.CoreV1().Secrets(ns).Get(p.Spec.SecretRef.Name, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("cannot get secret %s/%s: %s", ns, p.Spec.SecretRef.Name, err)
+	}
+	props := utils.Properties{}
+	for k, v := range secret.Data {
+		props[k] = string(v)
+	}
+	config := &provider.DNSHandlerConfig{
+		Context:    context.Background(),
+		Properties: props,
+		Config:     p.Spec.ProviderConfig,
+		DryRun:     true,
+	}
+	return factory.Create(logger.New(), config)
+}
+
+func newImportedEntry(namespace, ptype string, set *dns.DNSSet, types utils.StringSet, keepTTL bool) *api.DNSEntry {
+	entry := &api.DNSEntry{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: api.SchemeGroupVersion.String(),
+			Kind:       api.DNSEntryKind,
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      entryName(set.Name),
+			Namespace: namespace,
+		},
+	}
+	entry.Spec.DNSName = set.Name
+	entry.Spec.Type = ptype
+
+	var ttl int64
+	for _, t := range []string{dns.RS_A, dns.RS_AAAA, dns.RS_CNAME} {
+		if rs := set.Sets[t]; rs != nil && types.Contains(t) {
+			for _, r := range rs.Records {
+				entry.Spec.Targets = append(entry.Spec.Targets, strings.TrimSuffix(r.Value, "."))
+			}
+			ttl = rs.TTL
+		}
+	}
+	if rs := set.Sets[dns.RS_TXT]; rs != nil && types.Contains(dns.RS_TXT) {
+		if len(entry.Spec.Targets) > 0 {
+			fmt.Fprintf(os.Stderr, "skipping TXT records for %s: entry already has targets\n", set.Name)
+		} else {
+			for _, r := range rs.Records {
+				entry.Spec.Text = append(entry.Spec.Text, dns.UnquoteText(r.Value))
+			}
+			ttl = rs.TTL
+		}
+	}
+	if len(entry.Spec.Targets) == 0 && len(entry.Spec.Text) == 0 {
+		return nil
+	}
+	if keepTTL && ttl > 0 {
+		entry.Spec.TTL = &ttl
+	}
+	return entry
+}
+
+func entryName(dnsname string) string {
+	name := strings.Replace(dnsname, "*", "star", -1)
+	return strings.ToLower(strings.Replace(name, ".", "-", -1))
+}
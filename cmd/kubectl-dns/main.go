@@ -0,0 +1,110 @@
+/*
+ * Copyright 2019 SAP SE or an SAP affiliate company. All rights reserved. This file is licensed under the Apache Software License, v. 2 except as noted otherwise in the LICENSE file
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ *
+ */
+
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+
+	"github.com/gardener/external-dns-management/pkg/client/dns/clientset/versioned"
+)
+
+var Version string
+
+type options struct {
+	kubeconfig    string
+	context       string
+	namespace     string
+	allNamespaces bool
+}
+
+func main() {
+	opts := &options{}
+	cmd := &cobra.Command{
+		Use:          "kubectl-dns",
+		Short:        "inspect the state of the dns controller manager",
+		SilenceUsage: true,
+	}
+	flags := cmd.PersistentFlags()
+	flags.StringVar(&opts.kubeconfig, "kubeconfig", "", "path to the kubeconfig file")
+	flags.StringVar(&opts.context, "context", "", "kubeconfig context to use")
+	flags.StringVarP(&opts.namespace, "namespace", "n", "", "namespace to inspect (default from kubeconfig)")
+	flags.BoolVarP(&opts.allNamespaces, "all-namespaces", "A", false, "inspect all namespaces")
+
+	cmd.AddCommand(
+		newZonesCommand(opts),
+		newEntriesCommand(opts),
+		newProvidersCommand(opts),
+		newVerifyCommand(opts),
+		newImportCommand(opts),
+		newRestoreCommand(opts),
+		&cobra.Command{
+			Use:   "version",
+			Short: "print the version",
+			Run: func(*cobra.Command, []string) {
+				fmt.Println(Version)
+			},
+		},
+	)
+
+	if err := cmd.Execute(); err != nil {
+		os.Exit(1)
+	}
+}
+
+func (this *options) config() (*rest.Config, string, error) {
+	rules := clientcmd.NewDefaultClientConfigLoadingRules()
+	if this.kubeconfig != "" {
+		rules.ExplicitPath = this.kubeconfig
+	}
+	overrides := &clientcmd.ConfigOverrides{CurrentContext: this.context}
+	cfg := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(rules, overrides)
+
+	namespace := this.namespace
+	if namespace == "" {
+		ns, _, err := cfg.Namespace()
+		if err != nil {
+			return nil, "", err
+		}
+		namespace = ns
+	}
+	if this.allNamespaces {
+		namespace = ""
+	}
+
+	restcfg, err := cfg.ClientConfig()
+	if err != nil {
+		return nil, "", fmt.Errorf("cannot read kubeconfig: %s", err)
+	}
+	return restcfg, namespace, nil
+}
+
+func (this *options) clientset() (*versioned.Clientset, string, error) {
+	restcfg, namespace, err := this.config()
+	if err != nil {
+		return nil, "", err
+	}
+	cs, err := versioned.NewForConfig(restcfg)
+	if err != nil {
+		return nil, "", err
+	}
+	return cs, namespace, nil
+}
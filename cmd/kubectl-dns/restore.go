@@ -0,0 +1,113 @@
+/*
+ * Copyright 2019 SAP SE or an SAP affiliate company. All rights reserved. This file is licensed under the Apache Software License, v. 2 except as noted otherwise in the LICENSE file
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ *
+ */
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/ghodss/yaml"
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/gardener/controller-manager-library/pkg/resources"
+	"github.com/gardener/controller-manager-library/pkg/utils"
+
+	"github.com/gardener/external-dns-management/pkg/dns"
+	"github.com/gardener/external-dns-management/pkg/dns/provider"
+)
+
+type restoreOptions struct {
+	target string
+	types  []string
+	ttl    bool
+}
+
+func newRestoreCommand(opts *options) *cobra.Command {
+	ropts := &restoreOptions{}
+	cmd := &cobra.Command{
+		Use:   "restore <backup config map>",
+		Short: "generate DNSEntry manifests for the records found in a zone backup",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(_ *cobra.Command, args []string) error {
+			return runRestore(opts, ropts, args[0])
+		},
+	}
+	flags := cmd.Flags()
+	flags.StringVar(&ropts.target, "target-namespace", "", "namespace for the generated entries (default: namespace of backup provider)")
+	flags.StringSliceVar(&ropts.types, "types", []string{dns.RS_A, dns.RS_AAAA, dns.RS_CNAME, dns.RS_TXT}, "record types to restore")
+	flags.BoolVar(&ropts.ttl, "ttl", true, "keep the ttl of the record sets")
+	return cmd
+}
+
+func runRestore(opts *options, ropts *restoreOptions, name string) error {
+	cfg, namespace, err := opts.config()
+	if err != nil {
+		return err
+	}
+	kube, err := kubernetes.NewForConfig(cfg)
+	if err != nil {
+		return err
+	}
+	cm, err := kube.CoreV1().ConfigMaps(namespace).Get(name, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("cannot get backup %s/%s: %s", namespace, name, err)
+	}
+	data, ok := cm.Data[provider.BACKUP_KEY]
+	if !ok {
+		return fmt.Errorf("config map %s/%s contains no zone backup", namespace, name)
+	}
+	backup := &provider.ZoneBackup{}
+	if err := json.Unmarshal([]byte(data), backup); err != nil {
+		return fmt.Errorf("invalid zone backup %s/%s: %s", namespace, name, err)
+	}
+
+	target := ropts.target
+	if target == "" {
+		target = namespace
+		if pname, err := resources.ParseObjectName(backup.Provider); err == nil && pname.Namespace() != "" {
+			target = pname.Namespace()
+		}
+	}
+	types := utils.NewStringSetByArray(ropts.types)
+
+	names := []string{}
+	for n := range backup.Records {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	fmt.Fprintf(os.Stderr, "zone %s (%s) backup from %s: %d dns names\n", backup.Zone, backup.Domain, backup.Time, len(names))
+	for _, n := range names {
+		set := dns.NewDNSSet(n)
+		for ty, rs := range backup.Records[n].Sets {
+			set.SetRecordSet(ty, rs.TTL, rs.Records...)
+		}
+		entry := newImportedEntry(target, backup.Type, set, types, ropts.ttl)
+		if entry == nil {
+			continue
+		}
+		out, err := yaml.Marshal(entry)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("---\n%s", out)
+	}
+	return nil
+}
@@ -0,0 +1,108 @@
+/*
+ * Copyright 2019 SAP SE or an SAP affiliate company. All rights reserved. This file is licensed under the Apache Software License, v. 2 except as noted otherwise in the LICENSE file
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ *
+ */
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/gardener/controller-manager-library/pkg/utils"
+	"github.com/spf13/cobra"
+
+	api "github.com/gardener/external-dns-management/pkg/apis/dns/v1alpha1"
+	"github.com/gardener/external-dns-management/pkg/dns"
+)
+
+func newVerifyCommand(opts *options) *cobra.Command {
+	var server string
+	cmd := &cobra.Command{
+		Use:   "verify",
+		Short: "lookup the dns names of ready entries and compare them with the effective targets",
+		RunE: func(*cobra.Command, []string) error {
+			resolver := net.DefaultResolver
+			if server != "" {
+				r, err := dns.NewResolver(server)
+				if err != nil {
+					return err
+				}
+				resolver = r
+			}
+			v, err := opts.loadView()
+			if err != nil {
+				return err
+			}
+			failed := 0
+			w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+			fmt.Fprintln(w, "DNSNAME\tENTRY\tRESULT\tFOUND")
+			for i := range v.entries {
+				e := &v.entries[i]
+				if e.Status.State != api.STATE_READY {
+					continue
+				}
+				result, found := verify(resolver, e)
+				if result != "ok" {
+					failed++
+				}
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", e.Spec.DNSName, name(&e.ObjectMeta), result, orNone(found))
+			}
+			w.Flush()
+			if failed > 0 {
+				return fmt.Errorf("%d entries not verified", failed)
+			}
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&server, "resolver", "", "name server used for the lookups (host[:port], tls://host[:port] or https://url)")
+	return cmd
+}
+
+func verify(resolver *net.Resolver, e *api.DNSEntry) (string, string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if len(e.Spec.Text) > 0 {
+		txts, err := resolver.LookupTXT(ctx, e.Spec.DNSName)
+		if err != nil {
+			return "lookup failed", err.Error()
+		}
+		return compare(utils.NewStringSet(e.Spec.Text...), utils.NewStringSet(txts...)), strings.Join(txts, ",")
+	}
+	if len(e.Status.Targets) == 1 && net.ParseIP(e.Status.Targets[0]) == nil {
+		cname, err := resolver.LookupCNAME(ctx, e.Spec.DNSName)
+		if err != nil {
+			return "lookup failed", err.Error()
+		}
+		cname = strings.TrimSuffix(cname, ".")
+		return compare(utils.NewStringSet(e.Status.Targets...), utils.NewStringSet(cname)), cname
+	}
+	addrs, err := resolver.LookupHost(ctx, e.Spec.DNSName)
+	if err != nil {
+		return "lookup failed", err.Error()
+	}
+	return compare(utils.NewStringSet(e.Status.Targets...), utils.NewStringSet(addrs...)), strings.Join(addrs, ",")
+}
+
+func compare(expected, found utils.StringSet) string {
+	if expected.Equals(found) {
+		return "ok"
+	}
+	return "mismatch"
+}
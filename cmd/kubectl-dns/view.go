@@ -0,0 +1,112 @@
+/*
+ * Copyright 2019 SAP SE or an SAP affiliate company. All rights reserved. This file is licensed under the Apache Software License, v. 2 except as noted otherwise in the LICENSE file
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ *
+ */
+
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/gardener/controller-manager-library/pkg/utils"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	api "github.com/gardener/external-dns-management/pkg/apis/dns/v1alpha1"
+	dnsutils "github.com/gardener/external-dns-management/pkg/dns/utils"
+)
+
+// view is the effective state of the dns controller manager as it can
+// be derived from the DNSProvider and DNSEntry objects.
+type view struct {
+	providers []api.DNSProvider
+	entries   []api.DNSEntry
+}
+
+func (this *options) loadView() (*view, error) {
+	cs, namespace, err := this.clientset()
+	if err != nil {
+		return nil, err
+	}
+	plist, err := cs.KracV1alpha1().DNSProviders(namespace).List(metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("cannot list dns providers: %s", err)
+	}
+	elist, err := cs.KracV1alpha1().DNSEntries(namespace).List(metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("cannot list dns entries: %s", err)
+	}
+	v := &view{providers: plist.Items, entries: elist.Items}
+	sort.Slice(v.providers, func(i, j int) bool { return name(&v.providers[i].ObjectMeta) < name(&v.providers[j].ObjectMeta) })
+	sort.Slice(v.entries, func(i, j int) bool { return v.entries[i].Spec.DNSName < v.entries[j].Spec.DNSName })
+	return v, nil
+}
+
+// providerFor determines the provider responsible for an entry the same
+// way the provisioning controllers do: the provider with the longest
+// matching included domain wins.
+func (this *view) providerFor(e *api.DNSEntry) *api.DNSProvider {
+	var found *api.DNSProvider
+	match := 0
+	for i := range this.providers {
+		p := &this.providers[i]
+		if e.Spec.Type != "" && p.Spec.Type != e.Spec.Type {
+			continue
+		}
+		ilen := dnsutils.MatchSet(e.Spec.DNSName, utils.NewStringSetByArray(p.Status.Domains.Included))
+		elen := dnsutils.MatchSet(e.Spec.DNSName, utils.NewStringSetByArray(p.Status.Domains.Excluded))
+		if n := ilen - elen; n > match {
+			match = n
+			found = p
+		}
+	}
+	return found
+}
+
+func name(meta *metav1.ObjectMeta) string {
+	return meta.Namespace + "/" + meta.Name
+}
+
+func owners(meta *metav1.ObjectMeta) string {
+	list := []string{}
+	for _, o := range meta.OwnerReferences {
+		list = append(list, o.Kind+"/"+o.Name)
+	}
+	if len(list) == 0 {
+		return "-"
+	}
+	return strings.Join(list, ",")
+}
+
+func zone(e *api.DNSEntry) string {
+	if e.Status.Zone == nil || *e.Status.Zone == "" {
+		return "-"
+	}
+	return *e.Status.Zone
+}
+
+func message(msg *string) string {
+	if msg == nil {
+		return ""
+	}
+	return *msg
+}
+
+func orNone(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
@@ -0,0 +1,117 @@
+/*
+ * Copyright 2019 SAP SE or an SAP affiliate company. All rights reserved. This file is licensed under the Apache Software License, v. 2 except as noted otherwise in the LICENSE file
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ *
+ */
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	api "github.com/gardener/external-dns-management/pkg/apis/dns/v1alpha1"
+)
+
+func newZonesCommand(opts *options) *cobra.Command {
+	return &cobra.Command{
+		Use:   "zones",
+		Short: "show the dns entries grouped by hosted zone and provider",
+		RunE: func(*cobra.Command, []string) error {
+			v, err := opts.loadView()
+			if err != nil {
+				return err
+			}
+			zones := map[string][]*api.DNSEntry{}
+			order := []string{}
+			for i := range v.entries {
+				e := &v.entries[i]
+				z := zone(e)
+				if zones[z] == nil {
+					order = append(order, z)
+				}
+				zones[z] = append(zones[z], e)
+			}
+			w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+			fmt.Fprintln(w, "ZONE\tDNSNAME\tENTRY\tPROVIDER\tOWNER\tSTATE")
+			for _, z := range order {
+				for _, e := range zones[z] {
+					provider := "-"
+					if p := v.providerFor(e); p != nil {
+						provider = fmt.Sprintf("%s(%s)", name(&p.ObjectMeta), p.Spec.Type)
+					}
+					fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", z, e.Spec.DNSName, name(&e.ObjectMeta), provider, owners(&e.ObjectMeta), orNone(e.Status.State))
+				}
+			}
+			return w.Flush()
+		},
+	}
+}
+
+func newEntriesCommand(opts *options) *cobra.Command {
+	pending := false
+	cmd := &cobra.Command{
+		Use:   "entries",
+		Short: "show the dns entries and their status",
+		RunE: func(*cobra.Command, []string) error {
+			v, err := opts.loadView()
+			if err != nil {
+				return err
+			}
+			w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+			fmt.Fprintln(w, "ENTRY\tDNSNAME\tTYPE\tZONE\tSTATE\tTARGETS\tMESSAGE")
+			for i := range v.entries {
+				e := &v.entries[i]
+				if pending && e.Status.State == api.STATE_READY {
+					continue
+				}
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n", name(&e.ObjectMeta), e.Spec.DNSName, orNone(e.Spec.Type), zone(e),
+					orNone(e.Status.State), orNone(strings.Join(e.Status.Targets, ",")), message(e.Status.Message))
+			}
+			return w.Flush()
+		},
+	}
+	cmd.Flags().BoolVar(&pending, "pending", false, "show only entries with pending changes")
+	return cmd
+}
+
+func newProvidersCommand(opts *options) *cobra.Command {
+	return &cobra.Command{
+		Use:   "providers",
+		Short: "show the dns providers and their domains",
+		RunE: func(*cobra.Command, []string) error {
+			v, err := opts.loadView()
+			if err != nil {
+				return err
+			}
+			w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+			fmt.Fprintln(w, "PROVIDER\tTYPE\tSTATE\tINCLUDED\tEXCLUDED\tENTRIES")
+			for i := range v.providers {
+				p := &v.providers[i]
+				count := 0
+				for j := range v.entries {
+					if v.providerFor(&v.entries[j]) == p {
+						count++
+					}
+				}
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%d\n", name(&p.ObjectMeta), p.Spec.Type, orNone(p.Status.State),
+					orNone(strings.Join(p.Status.Domains.Included, ",")), orNone(strings.Join(p.Status.Domains.Excluded, ",")), count)
+			}
+			return w.Flush()
+		},
+	}
+}
@@ -41,17 +41,106 @@ type DNSEntry struct {
 }
 
 type DNSEntrySpec struct {
-	Type                string   `json:"type,omitempty"`
-	DNSName             string   `json:"dnsName"`
-	TTL                 *int64   `json:"ttl,omitempty"`
-	CNameLookupInterval *int64   `json:"cnameLookupInterval,omitempty"`
-	Text                []string `json:"text,omitempty"`
-	Targets             []string `json:"targets,omitempt"`
+	Type                string                `json:"type,omitempty"`
+	DNSName             string                `json:"dnsName"`
+	TTL                 *int64                `json:"ttl,omitempty"`
+	CNameLookupInterval *int64                `json:"cnameLookupInterval,omitempty"`
+	Text                []string              `json:"text,omitempty"`
+	Targets             []string              `json:"targets,omitempt"`
+	ProviderRef         *DNSProviderReference `json:"providerRef,omitempty"`
+	HealthCheck         *HealthCheck          `json:"healthCheck,omitempty"`
+	// PrivateTargets are used instead of the targets for private
+	// hosted zones (split-horizon).
+	PrivateTargets []string `json:"privateTargets,omitempty"`
+	// DeletionPolicy is Delete or Orphan. The default is given by the
+	// responsible provider.
+	DeletionPolicy string `json:"deletionPolicy,omitempty"`
+	// TargetRefs adds the actual targets of other entries to the
+	// targets of this entry.
+	TargetRefs []DNSEntryReference `json:"targetRefs,omitempty"`
+	// Flatten maintains the addresses of a hostname target as A and
+	// AAAA records instead of a CNAME record. The hostname is
+	// resolved again every CNameLookupInterval seconds.
+	Flatten bool `json:"flatten,omitempty"`
+	// ProviderMetadata contains provider specific settings for the
+	// records of the entry. Keys not known by the provider are ignored.
+	ProviderMetadata map[string]string `json:"providerMetadata,omitempty"`
+}
+
+const (
+	// DELETION_POLICY_DELETE deletes the records in the provider.
+	DELETION_POLICY_DELETE = "Delete"
+	// DELETION_POLICY_ORPHAN keeps the records in the provider, only
+	// the ownership is removed.
+	DELETION_POLICY_ORPHAN = "Orphan"
+)
+
+const (
+	HEALTHCHECK_HTTP  = "http"
+	HEALTHCHECK_HTTPS = "https"
+	HEALTHCHECK_TCP   = "tcp"
+)
+
+// HealthCheck describes a probe for the targets of an entry. Unhealthy
+// targets are omitted from the record set.
+type HealthCheck struct {
+	// Type is one of http, https or tcp.
+	Type string `json:"type"`
+	// Port is required for tcp, the default for http(s) is 80 or 443.
+	Port int `json:"port,omitempty"`
+	// Path is the request path for http(s) probes.
+	Path string `json:"path,omitempty"`
+	// Host is the host header used for http(s) probes.
+	Host string `json:"host,omitempty"`
+	// Interval in seconds between two probes (default 30).
+	Interval *int64 `json:"interval,omitempty"`
+	// Timeout in seconds for a single probe (default 5).
+	Timeout *int64 `json:"timeout,omitempty"`
+}
+
+// DNSProviderReference explicitly selects the provider used for an entry.
+// If no namespace is given, the namespace of the entry is used.
+type DNSProviderReference struct {
+	Namespace string `json:"namespace,omitempty"`
+	Name      string `json:"name"`
+}
+
+// DNSEntryReference refers to another entry.
+// If no namespace is given, the namespace of the entry is used. Entries
+// of other namespaces require the RBAC verb use for the referenced entry.
+type DNSEntryReference struct {
+	Namespace string `json:"namespace,omitempty"`
+	Name      string `json:"name"`
 }
 
 type DNSEntryStatus struct {
-	State   string   `json:"state"`
-	Message *string  `json:"message,omitempty"`
+	State   string  `json:"state"`
+	Message *string `json:"message,omitempty"`
+	// Reason classifies the cause of the Error state, it is Throttled
+	// for temporary errors because of rate limiting.
+	Reason  string   `json:"reason,omitempty"`
 	Zone    *string  `json:"zone,omitempty"`
+	TTL     *int64   `json:"ttl,omitempty"`
 	Targets []string `json:"targets,omitempty"`
+	// UnhealthyTargets lists the targets omitted because of a failed
+	// health check.
+	UnhealthyTargets []string `json:"unhealthyTargets,omitempty"`
+	// LastSyncTime is the time the records of the entry have last been
+	// changed in the provider.
+	LastSyncTime *metav1.Time `json:"lastSyncTime,omitempty"`
+	// Fingerprint is a hash of the records of the entry applied to
+	// the provider.
+	Fingerprint string `json:"fingerprint,omitempty"`
+	// Propagation is the result of resolving the dns name (if enabled).
+	Propagation *PropagationStatus `json:"propagation,omitempty"`
+}
+
+// PropagationStatus describes the observed resolution of the dns name.
+type PropagationStatus struct {
+	// State is one of Propagated, Pending or Timeout.
+	State   string  `json:"state"`
+	Message *string `json:"message,omitempty"`
+	// Fingerprint of the records checked.
+	Fingerprint   string      `json:"fingerprint,omitempty"`
+	LastCheckTime metav1.Time `json:"lastCheckTime"`
 }
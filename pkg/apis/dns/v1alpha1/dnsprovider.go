@@ -47,6 +47,45 @@ type DNSProviderSpec struct {
 	ProviderConfig *runtime.RawExtension   `json:"providerConfig,omitempty"`
 	SecretRef      *corev1.SecretReference `json:"secretRef,omitempty"`
 	Domains        *DNSDomainSpec          `json:"domains,omitempty"`
+	RateLimit      *RateLimit              `json:"rateLimit,omitempty"`
+	DefaultTTL     *int64                  `json:"defaultTTL,omitempty"`
+	MinTTL         *int64                  `json:"minTTL,omitempty"`
+	MaxTTL         *int64                  `json:"maxTTL,omitempty"`
+	Quota          *Quota                  `json:"quota,omitempty"`
+	// SyncInterval is the interval in seconds the hosted zones of the
+	// provider are completely read again and reconciled. It overrides
+	// the zone cache ttl of the controller.
+	SyncInterval *int64 `json:"syncInterval,omitempty"`
+	// AllowedNamespaces lists the namespaces of entries allowed
+	// to explicitly reference this provider ("*" for all namespaces).
+	AllowedNamespaces []string `json:"allowedNamespaces,omitempty"`
+	// DomainAllowlists restrict the namespaces allowed to create entries
+	// for the domains of the provider. Without allowlists entries of
+	// all namespaces are accepted.
+	DomainAllowlists []DomainAllowlist `json:"domainAllowlists,omitempty"`
+	// DeletionPolicy is Delete or Orphan (default Delete). It is used
+	// for the deletion of the provider and as default for its entries.
+	DeletionPolicy string `json:"deletionPolicy,omitempty"`
+}
+
+// DomainAllowlist permits entries of the selected namespaces
+// for the listed domains and their subdomains.
+type DomainAllowlist struct {
+	Namespaces        []string              `json:"namespaces,omitempty"`
+	NamespaceSelector *metav1.LabelSelector `json:"namespaceSelector,omitempty"`
+	Domains           []string              `json:"domains"`
+}
+
+// Quota restricts the number of entries handled by a provider.
+type Quota struct {
+	MaxEntries int `json:"maxEntries,omitempty"`
+}
+
+// RateLimit limits the requests sent to the provider API by this
+// provider object.
+type RateLimit struct {
+	RequestsPerSecond int `json:"requestsPerSecond"`
+	Burst             int `json:"burst,omitempty"`
 }
 
 type DNSDomainSpec struct {
@@ -58,6 +97,19 @@ type DNSProviderStatus struct {
 	State   string          `json:"state"`
 	Message *string         `json:"message,omitempty"`
 	Domains DNSDomainStatus `json:"domains"`
+	Entries *int            `json:"entries,omitempty"`
+	// StaleRecords are the record sets owned by the controller in the
+	// hosted zones of the provider without a corresponding entry, which
+	// are not (yet) deleted.
+	StaleRecords []StaleRecord `json:"staleRecords,omitempty"`
+}
+
+// StaleRecord describes a record set owned by the controller
+// without a corresponding entry.
+type StaleRecord struct {
+	DNSName string      `json:"dnsName"`
+	Zone    string      `json:"zone"`
+	Since   metav1.Time `json:"since"`
 }
 
 type DNSDomainStatus struct {
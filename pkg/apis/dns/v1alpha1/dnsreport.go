@@ -0,0 +1,74 @@
+/*
+ * Copyright 2019 SAP SE or an SAP affiliate company. All rights reserved. This file is licensed under the Apache Software License, v. 2 except as noted otherwise in the LICENSE file
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ *
+ */
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+type DNSReportList struct {
+	metav1.TypeMeta `json:",inline"`
+	// Standard list metadata
+	// More info: http://releases.k8s.io/HEAD/docs/devel/api-conventions.md#metadata
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []DNSReport `json:"items"`
+}
+
+// +genclient
+// +genclient:nonNamespaced
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// DNSReport summarizes the state of the providers and entries
+// handled by a dns controller. It is maintained by the controller.
+type DNSReport struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+	Status            DNSReportStatus `json:"status"`
+}
+
+type DNSReportStatus struct {
+	// Identifier of the controller.
+	Identifier string `json:"identifier"`
+	// ProviderType handled by the controller.
+	ProviderType string `json:"providerType"`
+	// Healthy is false if any provider or entry is not ready.
+	Healthy   bool              `json:"healthy"`
+	Providers []DNSProviderInfo `json:"providers,omitempty"`
+	// Entries counts all entries of the controller by their state.
+	Entries        map[string]int `json:"entries,omitempty"`
+	StaleEntries   int            `json:"staleEntries"`
+	LastUpdateTime metav1.Time    `json:"lastUpdateTime"`
+}
+
+// DNSProviderInfo summarizes the state of a provider.
+type DNSProviderInfo struct {
+	// Name is the object name of the provider (<namespace>/<name>).
+	Name  string `json:"name"`
+	State string `json:"state"`
+	// Zones are the ids of the hosted zones managed by the provider.
+	Zones []string `json:"zones,omitempty"`
+	// Entries counts the entries of the provider by their state.
+	Entries map[string]int `json:"entries,omitempty"`
+	// StaleEntries is the number of entries not ready since
+	// more than ten minutes.
+	StaleEntries int `json:"staleEntries"`
+	// LastSyncTime is the time of the last successful reconcilation
+	// of all zones of the provider.
+	LastSyncTime *metav1.Time `json:"lastSyncTime,omitempty"`
+}
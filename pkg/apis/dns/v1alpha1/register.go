@@ -33,6 +33,9 @@ const (
 
 	DNSEntryKind   = "DNSEntry"
 	DNSEntryPlural = "dnsentries"
+
+	DNSReportKind   = "DNSReport"
+	DNSReportPlural = "dnsreports"
 )
 
 // SchemeGroupVersion is group version used to register these objects
@@ -60,6 +63,8 @@ func addKnownTypes(scheme *runtime.Scheme) error {
 		&DNSProviderList{},
 		&DNSEntry{},
 		&DNSEntryList{},
+		&DNSReport{},
+		&DNSReportList{},
 	)
 	metav1.AddToGroupVersion(scheme, SchemeGroupVersion)
 	return nil
@@ -20,3 +20,11 @@ const STATE_PENDING = "Pending"
 const STATE_ERROR = "Error"
 const STATE_INVALID = "Invalid"
 const STATE_READY = "Ready"
+
+// REASON_THROTTLED is the reason for an error caused by the rate
+// limiting of the provider API. Such errors are retried.
+const REASON_THROTTLED = "Throttled"
+
+const PROPAGATION_PROPAGATED = "Propagated"
+const PROPAGATION_PENDING = "Pending"
+const PROPAGATION_TIMEOUT = "Timeout"
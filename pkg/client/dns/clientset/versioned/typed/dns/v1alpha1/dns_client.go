@@ -29,6 +29,7 @@ type KracV1alpha1Interface interface {
 	RESTClient() rest.Interface
 	DNSEntriesGetter
 	DNSProvidersGetter
+	DNSReportsGetter
 }
 
 // KracV1alpha1Client is used to interact with features provided by the krac group.
@@ -44,6 +45,10 @@ func (c *KracV1alpha1Client) DNSProviders(namespace string) DNSProviderInterface
 	return newDNSProviders(c, namespace)
 }
 
+func (c *KracV1alpha1Client) DNSReports() DNSReportInterface {
+	return newDNSReports(c)
+}
+
 // NewForConfig creates a new KracV1alpha1Client for the given config.
 func NewForConfig(c *rest.Config) (*KracV1alpha1Client, error) {
 	config := *c
@@ -36,6 +36,10 @@ func (c *FakeKracV1alpha1) DNSProviders(namespace string) v1alpha1.DNSProviderIn
 	return &FakeDNSProviders{c, namespace}
 }
 
+func (c *FakeKracV1alpha1) DNSReports() v1alpha1.DNSReportInterface {
+	return &FakeDNSReports{c}
+}
+
 // RESTClient returns a RESTClient that is used to communicate
 // with API server by this client implementation.
 func (c *FakeKracV1alpha1) RESTClient() rest.Interface {
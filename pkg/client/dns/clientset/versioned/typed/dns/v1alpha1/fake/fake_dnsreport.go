@@ -0,0 +1,131 @@
+/*
+Copyright (c) 2018 SAP SE or an SAP affiliate company. All rights reserved. This file is licensed under the Apache Software License, v. 2 except as noted otherwise in the LICENSE file
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Code generated by client-gen. DO NOT EDIT.
+
+package fake
+
+import (
+	v1alpha1 "github.com/gardener/external-dns-management/pkg/apis/dns/v1alpha1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	labels "k8s.io/apimachinery/pkg/labels"
+	schema "k8s.io/apimachinery/pkg/runtime/schema"
+	types "k8s.io/apimachinery/pkg/types"
+	watch "k8s.io/apimachinery/pkg/watch"
+	testing "k8s.io/client-go/testing"
+)
+
+// FakeDNSReports implements DNSReportInterface
+type FakeDNSReports struct {
+	Fake *FakeKracV1alpha1
+}
+
+var dnsreportsResource = schema.GroupVersionResource{Group: "krac", Version: "v1alpha1", Resource: "dnsreports"}
+
+var dnsreportsKind = schema.GroupVersionKind{Group: "krac", Version: "v1alpha1", Kind: "DNSReport"}
+
+// Get takes name of the dNSReport, and returns the corresponding dNSReport object, and an error if there is any.
+func (c *FakeDNSReports) Get(name string, options v1.GetOptions) (result *v1alpha1.DNSReport, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewRootGetAction(dnsreportsResource, name), &v1alpha1.DNSReport{})
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*v1alpha1.DNSReport), err
+}
+
+// List takes label and field selectors, and returns the list of DNSReports that match those selectors.
+func (c *FakeDNSReports) List(opts v1.ListOptions) (result *v1alpha1.DNSReportList, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewRootListAction(dnsreportsResource, dnsreportsKind, opts), &v1alpha1.DNSReportList{})
+	if obj == nil {
+		return nil, err
+	}
+
+	label, _, _ := testing.ExtractFromListOptions(opts)
+	if label == nil {
+		label = labels.Everything()
+	}
+	list := &v1alpha1.DNSReportList{ListMeta: obj.(*v1alpha1.DNSReportList).ListMeta}
+	for _, item := range obj.(*v1alpha1.DNSReportList).Items {
+		if label.Matches(labels.Set(item.Labels)) {
+			list.Items = append(list.Items, item)
+		}
+	}
+	return list, err
+}
+
+// Watch returns a watch.Interface that watches the requested dNSReports.
+func (c *FakeDNSReports) Watch(opts v1.ListOptions) (watch.Interface, error) {
+	return c.Fake.
+		InvokesWatch(testing.NewRootWatchAction(dnsreportsResource, opts))
+}
+
+// Create takes the representation of a dNSReport and creates it.  Returns the server's representation of the dNSReport, and an error, if there is any.
+func (c *FakeDNSReports) Create(dNSReport *v1alpha1.DNSReport) (result *v1alpha1.DNSReport, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewRootCreateAction(dnsreportsResource, dNSReport), &v1alpha1.DNSReport{})
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*v1alpha1.DNSReport), err
+}
+
+// Update takes the representation of a dNSReport and updates it. Returns the server's representation of the dNSReport, and an error, if there is any.
+func (c *FakeDNSReports) Update(dNSReport *v1alpha1.DNSReport) (result *v1alpha1.DNSReport, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewRootUpdateAction(dnsreportsResource, dNSReport), &v1alpha1.DNSReport{})
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*v1alpha1.DNSReport), err
+}
+
+// UpdateStatus was generated because the type contains a Status member.
+// Add a +genclient:noStatus comment above the type to avoid generating UpdateStatus().
+func (c *FakeDNSReports) UpdateStatus(dNSReport *v1alpha1.DNSReport) (*v1alpha1.DNSReport, error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewRootUpdateSubresourceAction(dnsreportsResource, "status", dNSReport), &v1alpha1.DNSReport{})
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*v1alpha1.DNSReport), err
+}
+
+// Delete takes name of the dNSReport and deletes it. Returns an error if one occurs.
+func (c *FakeDNSReports) Delete(name string, options *v1.DeleteOptions) error {
+	_, err := c.Fake.
+		Invokes(testing.NewRootDeleteAction(dnsreportsResource, name), &v1alpha1.DNSReport{})
+	return err
+}
+
+// DeleteCollection deletes a collection of objects.
+func (c *FakeDNSReports) DeleteCollection(options *v1.DeleteOptions, listOptions v1.ListOptions) error {
+	action := testing.NewRootDeleteCollectionAction(dnsreportsResource, listOptions)
+
+	_, err := c.Fake.Invokes(action, &v1alpha1.DNSReportList{})
+	return err
+}
+
+// Patch applies the patch and returns the patched dNSReport.
+func (c *FakeDNSReports) Patch(name string, pt types.PatchType, data []byte, subresources ...string) (result *v1alpha1.DNSReport, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewRootPatchSubresourceAction(dnsreportsResource, name, data, subresources...), &v1alpha1.DNSReport{})
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*v1alpha1.DNSReport), err
+}
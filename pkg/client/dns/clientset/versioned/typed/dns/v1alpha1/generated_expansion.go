@@ -21,3 +21,5 @@ package v1alpha1
 type DNSEntryExpansion interface{}
 
 type DNSProviderExpansion interface{}
+
+type DNSReportExpansion interface{}
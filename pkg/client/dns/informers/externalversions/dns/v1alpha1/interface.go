@@ -28,6 +28,8 @@ type Interface interface {
 	DNSEntries() DNSEntryInformer
 	// DNSProviders returns a DNSProviderInformer.
 	DNSProviders() DNSProviderInformer
+	// DNSReports returns a DNSReportInformer.
+	DNSReports() DNSReportInformer
 }
 
 type version struct {
@@ -50,3 +52,8 @@ func (v *version) DNSEntries() DNSEntryInformer {
 func (v *version) DNSProviders() DNSProviderInformer {
 	return &dNSProviderInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
 }
+
+// DNSReports returns a DNSReportInformer.
+func (v *version) DNSReports() DNSReportInformer {
+	return &dNSReportInformer{factory: v.factory, tweakListOptions: v.tweakListOptions}
+}
@@ -57,6 +57,8 @@ func (f *sharedInformerFactory) ForResource(resource schema.GroupVersionResource
 		return &genericInformer{resource: resource.GroupResource(), informer: f.Krac().V1alpha1().DNSEntries().Informer()}, nil
 	case v1alpha1.SchemeGroupVersion.WithResource("dnsproviders"):
 		return &genericInformer{resource: resource.GroupResource(), informer: f.Krac().V1alpha1().DNSProviders().Informer()}, nil
+	case v1alpha1.SchemeGroupVersion.WithResource("dnsreports"):
+		return &genericInformer{resource: resource.GroupResource(), informer: f.Krac().V1alpha1().DNSReports().Informer()}, nil
 
 	}
 
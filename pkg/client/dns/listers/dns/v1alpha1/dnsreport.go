@@ -0,0 +1,65 @@
+/*
+Copyright (c) 2018 SAP SE or an SAP affiliate company. All rights reserved. This file is licensed under the Apache Software License, v. 2 except as noted otherwise in the LICENSE file
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Code generated by lister-gen. DO NOT EDIT.
+
+package v1alpha1
+
+import (
+	v1alpha1 "github.com/gardener/external-dns-management/pkg/apis/dns/v1alpha1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/tools/cache"
+)
+
+// DNSReportLister helps list DNSReports.
+type DNSReportLister interface {
+	// List lists all DNSReports in the indexer.
+	List(selector labels.Selector) (ret []*v1alpha1.DNSReport, err error)
+	// Get retrieves the DNSReport from the index for a given name.
+	Get(name string) (*v1alpha1.DNSReport, error)
+	DNSReportListerExpansion
+}
+
+// dNSReportLister implements the DNSReportLister interface.
+type dNSReportLister struct {
+	indexer cache.Indexer
+}
+
+// NewDNSReportLister returns a new DNSReportLister.
+func NewDNSReportLister(indexer cache.Indexer) DNSReportLister {
+	return &dNSReportLister{indexer: indexer}
+}
+
+// List lists all DNSReports in the indexer.
+func (s *dNSReportLister) List(selector labels.Selector) (ret []*v1alpha1.DNSReport, err error) {
+	err = cache.ListAll(s.indexer, selector, func(m interface{}) {
+		ret = append(ret, m.(*v1alpha1.DNSReport))
+	})
+	return ret, err
+}
+
+// Get retrieves the DNSReport from the index for a given name.
+func (s *dNSReportLister) Get(name string) (*v1alpha1.DNSReport, error) {
+	obj, exists, err := s.indexer.GetByKey(name)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, errors.NewNotFound(v1alpha1.Resource("dnsreport"), name)
+	}
+	return obj.(*v1alpha1.DNSReport), nil
+}
@@ -33,3 +33,7 @@ type DNSProviderListerExpansion interface{}
 // DNSProviderNamespaceListerExpansion allows custom methods to be added to
 // DNSProviderNamespaceLister.
 type DNSProviderNamespaceListerExpansion interface{}
+
+// DNSReportListerExpansion allows custom methods to be added to
+// DNSReportLister.
+type DNSReportListerExpansion interface{}
@@ -17,9 +17,12 @@
 package googledns
 
 import (
+	"time"
+
 	"github.com/gardener/controller-manager-library/pkg/logger"
 	"github.com/gardener/controller-manager-library/pkg/utils"
 	"github.com/gardener/external-dns-management/pkg/dns"
+	"github.com/gardener/external-dns-management/pkg/dns/metrics"
 	"github.com/gardener/external-dns-management/pkg/dns/provider"
 	googledns "google.golang.org/api/dns/v1"
 )
@@ -33,16 +36,43 @@ type Execution struct {
 	handler *Handler
 	zoneid  string
 
+	changes []*batch
+}
+
+// batch is a change request for the zone together with the done handlers
+// of the requests it is composed of.
+type batch struct {
 	change *googledns.Change
 	done   []provider.DoneHandler
 }
 
-func NewExecution(logger logger.LogContext, h *Handler, zoneid string) *Execution {
+func newBatch() *batch {
 	change := &googledns.Change{
 		Additions: []*googledns.ResourceRecordSet{},
 		Deletions: []*googledns.ResourceRecordSet{},
 	}
-	return &Execution{LogContext: logger, handler: h, zoneid: zoneid, change: change, done: []provider.DoneHandler{}}
+	return &batch{change: change, done: []provider.DoneHandler{}}
+}
+
+func (this *batch) size() int {
+	return len(this.change.Additions) + len(this.change.Deletions)
+}
+
+func NewExecution(logger logger.LogContext, h *Handler, zoneid string) *Execution {
+	return &Execution{LogContext: logger, handler: h, zoneid: zoneid, changes: []*batch{newBatch()}}
+}
+
+// current returns the batch to add a request with the given number of
+// record set changes to. All record set changes of a request are always
+// handled by the same batch.
+func (this *Execution) current(n int) *batch {
+	b := this.changes[len(this.changes)-1]
+	max := this.handler.config.MaxChangeCount
+	if max > 0 && b.size() > 0 && b.size()+n > max {
+		b = newBatch()
+		this.changes = append(this.changes, b)
+	}
+	return b
 }
 
 func (this *Execution) addChange(req *provider.ChangeRequest) {
@@ -62,47 +92,68 @@ func (this *Execution) addChange(req *provider.ChangeRequest) {
 	switch req.Action {
 	case provider.R_CREATE:
 		this.Infof("%s %s record set %s[%s]: %s", req.Action, req.Type, name, this.zoneid, newset.RecordString())
-		this.change.Additions = append(this.change.Additions, mapRecordSet(name, newset))
+		b := this.current(1)
+		b.change.Additions = append(b.change.Additions, mapRecordSet(name, newset))
+		b.done = append(b.done, req.Done)
 	case provider.R_DELETE:
 		this.Infof("%s %s record set %s[%s]: %s", req.Action, req.Type, name, this.zoneid, oldset.RecordString())
-		this.change.Deletions = append(this.change.Deletions, mapRecordSet(name, oldset))
+		b := this.current(1)
+		b.change.Deletions = append(b.change.Deletions, mapRecordSet(name, oldset))
+		b.done = append(b.done, req.Done)
 	case provider.R_UPDATE:
 		this.Infof("%s %s record set %s[%s]: %s", req.Action, req.Type, name, this.zoneid, newset.RecordString())
-		this.change.Deletions = append(this.change.Deletions, mapRecordSet(name, oldset))
-		this.change.Additions = append(this.change.Additions, mapRecordSet(name, newset))
+		b := this.current(2)
+		b.change.Deletions = append(b.change.Deletions, mapRecordSet(name, oldset))
+		b.change.Additions = append(b.change.Additions, mapRecordSet(name, newset))
+		b.done = append(b.done, req.Done)
 	}
-	this.done = append(this.done, req.Done)
 }
 
 func (this *Execution) submitChanges() error {
-
-	if len(this.change.Additions) == 0 && len(this.change.Deletions) == 0 {
-		return nil
+	var err error
+	for i, b := range this.changes {
+		if b.size() == 0 {
+			continue
+		}
+		this.Infof("processing batch %d for zone %s", i+1, this.zoneid)
+		if berr := this.submitBatch(b); berr != nil {
+			err = berr
+		}
 	}
+	return err
+}
 
-	this.Infof("processing changes for  zone %s", this.zoneid)
-	for _, c := range this.change.Deletions {
+func (this *Execution) submitBatch(b *batch) error {
+	for _, c := range b.change.Deletions {
 		this.Infof("desired change: Deletion %s %s: %s", c.Name, c.Type, utils.Strings(c.Rrdatas...))
 	}
-	for _, c := range this.change.Additions {
+	for _, c := range b.change.Additions {
 		this.Infof("desired change: Addition %s %s: %s", c.Name, c.Type, utils.Strings(c.Rrdatas...))
 	}
 
-	if _, err := this.handler.service.Changes.Create(this.handler.credentials.ProjectID, this.zoneid, this.change).Do(); err != nil {
+	start := time.Now()
+	metrics.AddRequests(TYPE_GOOGLE, this.zoneid, metrics.M_CHANGE, 1)
+	_, err := this.handler.service.Changes.Create(this.handler.credentials.ProjectID, this.zoneid, b.change).Do()
+	metrics.ObserveChangeBatch(TYPE_GOOGLE, this.zoneid, start, b.size())
+	if err != nil {
+		metrics.AddError(TYPE_GOOGLE, this.zoneid, metrics.M_CHANGE, isThrottled(err))
 		this.Error(err)
-		for _, d := range this.done {
+		if isThrottled(err) {
+			err = provider.NewThrottlingError(err)
+		}
+		for _, d := range b.done {
 			if d != nil {
 				d.Failed(err)
 			}
 		}
 		return err
 	} else {
-		for _, d := range this.done {
+		for _, d := range b.done {
 			if d != nil {
 				d.Succeeded()
 			}
 		}
-		this.Infof("%d records in zone %s were successfully updated", len(this.change.Additions)+len(this.change.Deletions), this.zoneid)
+		this.Infof("%d records in zone %s were successfully updated", b.size(), this.zoneid)
 		return nil
 	}
 }
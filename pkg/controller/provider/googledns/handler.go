@@ -21,14 +21,18 @@ import (
 	"fmt"
 	"github.com/gardener/external-dns-management/pkg/dns/provider"
 	"net/http"
+	"strconv"
 
 	"golang.org/x/oauth2"
 	"golang.org/x/oauth2/google"
 
 	"github.com/gardener/controller-manager-library/pkg/logger"
 	"github.com/gardener/external-dns-management/pkg/dns"
+	"github.com/gardener/external-dns-management/pkg/dns/metrics"
 
 	googledns "google.golang.org/api/dns/v1"
+	"google.golang.org/api/googleapi"
+	"k8s.io/client-go/util/flowcontrol"
 )
 
 type Handler struct {
@@ -40,6 +44,8 @@ type Handler struct {
 }
 
 var _ provider.DNSHandler = &Handler{}
+var _ provider.IncrementalDNSHandler = &Handler{}
+var _ provider.StreamingDNSHandler = &Handler{}
 
 func NewHandler(logger logger.LogContext, config *provider.DNSHandlerConfig) (provider.DNSHandler, error) {
 	var err error
@@ -69,6 +75,9 @@ func NewHandler(logger logger.LogContext, config *provider.DNSHandlerConfig) (pr
 		return nil, fmt.Errorf("serviceaccount is invalid: %s", err)
 	}
 	this.client = oauth2.NewClient(this.ctx, this.credentials.TokenSource)
+	if this.config.RateLimiter != nil {
+		this.client.Transport = &rateLimitedTransport{this.client.Transport, this.config.RateLimiter}
+	}
 	//this.client=cfg.Client(ctx)
 
 	this.service, err = googledns.New(this.client)
@@ -83,6 +92,7 @@ func (this *Handler) GetZones() (provider.DNSHostedZoneInfos, error) {
 	zones := provider.DNSHostedZoneInfos{}
 
 	f := func(resp *googledns.ManagedZonesListResponse) error {
+		metrics.AddRequests(TYPE_GOOGLE, "", metrics.M_LISTZONES, 1)
 		for _, zone := range resp.ManagedZones {
 			hostedZone := &provider.DNSHostedZoneInfo{
 				Id:     zone.Name,
@@ -94,6 +104,7 @@ func (this *Handler) GetZones() (provider.DNSHostedZoneInfos, error) {
 	}
 
 	if err := this.service.ManagedZones.List(this.credentials.ProjectID).Pages(this.ctx, f); err != nil {
+		metrics.AddError(TYPE_GOOGLE, "", metrics.M_LISTZONES, isThrottled(err))
 		return nil, err
 	}
 	return zones, nil
@@ -101,28 +112,129 @@ func (this *Handler) GetZones() (provider.DNSHostedZoneInfos, error) {
 
 func (this *Handler) GetDNSSets(zoneid string) (dns.DNSSets, error) {
 	dnssets := dns.DNSSets{}
+	if err := this.VisitDNSSets(zoneid, dnssets.AddRecordSetFromProvider); err != nil {
+		return nil, err
+	}
+	return dnssets, nil
+}
 
+func (this *Handler) VisitDNSSets(zoneid string, visitor func(dnsname string, rs *dns.RecordSet)) error {
 	f := func(resp *googledns.ResourceRecordSetsListResponse) error {
+		metrics.AddRequests(TYPE_GOOGLE, zoneid, metrics.M_LISTRECORDS, 1)
 		for _, r := range resp.Rrsets {
-			if !dns.SupportedRecordType(r.Type) {
-				continue
+			if rs := mapFromRecordSet(r); rs != nil {
+				visitor(r.Name, rs)
 			}
+		}
+		return nil
+	}
 
-			rs := dns.NewRecordSet(r.Type, r.Ttl, nil)
-			for _, rr := range r.Rrdatas {
-				rs.Add(&dns.Record{Value: rr})
+	if err := this.service.ResourceRecordSets.List(this.credentials.ProjectID, zoneid).Pages(this.ctx, f); err != nil {
+		metrics.AddError(TYPE_GOOGLE, zoneid, metrics.M_LISTRECORDS, isThrottled(err))
+		return err
+	}
+	return nil
+}
+
+func (this *Handler) GetDNSSetsForName(zoneid string, dnsname string) (dns.DNSSets, error) {
+	dnssets := dns.DNSSets{}
+	for _, name := range append([]string{dnsname}, dns.MetaRecordNames(dnsname)...) {
+		metrics.AddRequests(TYPE_GOOGLE, zoneid, metrics.M_LISTRECORDS, 1)
+		resp, err := this.service.ResourceRecordSets.List(this.credentials.ProjectID, zoneid).
+			Name(dns.AlignHostname(name)).Context(this.ctx).Do()
+		if err != nil {
+			metrics.AddError(TYPE_GOOGLE, zoneid, metrics.M_LISTRECORDS, isThrottled(err))
+			return nil, err
+		}
+		for _, r := range resp.Rrsets {
+			if rs := mapFromRecordSet(r); rs != nil {
+				dnssets.AddRecordSetFromProvider(r.Name, rs)
 			}
+		}
+	}
+	return dnssets, nil
+}
 
-			dnssets.AddRecordSetFromProvider(r.Name, rs)
+func (this *Handler) GetZoneStateToken(zoneid string) (string, error) {
+	metrics.AddRequests(TYPE_GOOGLE, zoneid, metrics.M_LISTCHANGES, 1)
+	resp, err := this.service.Changes.List(this.credentials.ProjectID, zoneid).
+		SortBy("changeSequence").SortOrder("descending").MaxResults(1).Context(this.ctx).Do()
+	if err != nil {
+		metrics.AddError(TYPE_GOOGLE, zoneid, metrics.M_LISTCHANGES, isThrottled(err))
+		return "", err
+	}
+	if len(resp.Changes) == 0 {
+		return "", nil
+	}
+	return resp.Changes[0].Id, nil
+}
+
+var errChangesDone = fmt.Errorf("all changes read")
+
+func (this *Handler) GetZoneChanges(zoneid string, token string) ([]*provider.DNSZoneChange, string, error) {
+	changes := []*provider.DNSZoneChange{}
+	last, err := strconv.ParseInt(token, 10, 64)
+	if err != nil {
+		last = -1
+	}
+	newtoken := token
+
+	// changes are read in descending order to stop reading at the last known change
+	f := func(resp *googledns.ChangesListResponse) error {
+		metrics.AddRequests(TYPE_GOOGLE, zoneid, metrics.M_LISTCHANGES, 1)
+		for _, c := range resp.Changes {
+			id, err := strconv.ParseInt(c.Id, 10, 64)
+			if err != nil {
+				return fmt.Errorf("invalid change id %q: %s", c.Id, err)
+			}
+			if id <= last {
+				return errChangesDone
+			}
+			if len(changes) == 0 {
+				newtoken = c.Id
+			}
+			changes = append(changes, &provider.DNSZoneChange{
+				Deletions: mapRecordSets(c.Deletions),
+				Additions: mapRecordSets(c.Additions),
+			})
 		}
 		return nil
 	}
 
-	if err := this.service.ResourceRecordSets.List(this.credentials.ProjectID, zoneid).Pages(this.ctx, f); err != nil {
-		return nil, err
+	err = this.service.Changes.List(this.credentials.ProjectID, zoneid).
+		SortBy("changeSequence").SortOrder("descending").Pages(this.ctx, f)
+	if err != nil && err != errChangesDone {
+		metrics.AddError(TYPE_GOOGLE, zoneid, metrics.M_LISTCHANGES, isThrottled(err))
+		return nil, token, err
+	}
+	for i, j := 0, len(changes)-1; i < j; i, j = i+1, j-1 {
+		changes[i], changes[j] = changes[j], changes[i]
+	}
+	return changes, newtoken, nil
+}
+
+func mapRecordSets(rrsets []*googledns.ResourceRecordSet) dns.DNSSets {
+	dnssets := dns.DNSSets{}
+	for _, r := range rrsets {
+		if rs := mapFromRecordSet(r); rs != nil {
+			dnssets.AddRecordSetFromProvider(r.Name, rs)
+		}
 	}
+	return dnssets
+}
 
-	return dnssets, nil
+func mapFromRecordSet(r *googledns.ResourceRecordSet) *dns.RecordSet {
+	if !dns.SupportedRecordType(r.Type) {
+		return nil
+	}
+	rs := dns.NewRecordSet(r.Type, r.Ttl, nil)
+	for _, rr := range r.Rrdatas {
+		if r.Type == dns.RS_TXT {
+			rr = dns.NormalizeText(rr)
+		}
+		rs.Add(&dns.Record{Value: rr})
+	}
+	return rs
 }
 
 func (this *Handler) ExecuteRequests(logger logger.LogContext, zoneid string, reqs []*provider.ChangeRequest) error {
@@ -137,3 +249,29 @@ func (this *Handler) ExecuteRequests(logger logger.LogContext, zoneid string, re
 	}
 	return exec.submitChanges()
 }
+
+// rateLimitedTransport delays the requests to the Google API according to the
+// rate limit of the provider.
+type rateLimitedTransport struct {
+	base    http.RoundTripper
+	limiter flowcontrol.RateLimiter
+}
+
+func (this *rateLimitedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	this.limiter.Accept()
+	return this.base.RoundTrip(req)
+}
+
+func isThrottled(err error) bool {
+	if gerr, ok := err.(*googleapi.Error); ok {
+		if gerr.Code == http.StatusTooManyRequests {
+			return true
+		}
+		for _, e := range gerr.Errors {
+			if e.Reason == "rateLimitExceeded" || e.Reason == "userRateLimitExceeded" {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,59 @@
+/*
+ * Copyright 2019 SAP SE or an SAP affiliate company. All rights reserved. This file is licensed under the Apache Software License, v. 2 except as noted otherwise in the LICENSE file
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ *
+ */
+
+package mock
+
+import (
+	"context"
+
+	"github.com/gardener/controller-manager-library/pkg/controllermanager/config"
+
+	"github.com/gardener/external-dns-management/pkg/dns/provider"
+)
+
+const CONTROLLER_NAME = "mock-dns-controller"
+
+// CONTROLLER_GROUP_MOCK is the controller group of the mock controller.
+// It is not part of the default dns controllers and must be enabled
+// explicitly.
+const CONTROLLER_GROUP_MOCK = "mock"
+
+// OPT_DNS_SERVER is the option for the UDP address of the name server
+// answering queries for the records of all mock zones.
+const OPT_DNS_SERVER = "mock-dns-server"
+
+func init() {
+	config.RegisterExtension(func(cfg *config.Config) {
+		opt, _ := cfg.AddStringOption(OPT_DNS_SERVER)
+		opt.Description = "UDP address of an authoritative name server for the zones of the mock provider (default: none)"
+	})
+	provider.DNSController(CONTROLLER_NAME, &Factory{}).
+		FinalizerDomain("dns.gardener.cloud").
+		MustRegister(CONTROLLER_GROUP_MOCK)
+}
+
+// dnsServer returns the configured address of the mock name server.
+func dnsServer(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	if cfg := config.Get(ctx); cfg != nil {
+		if o := cfg.GetOption(OPT_DNS_SERVER); o != nil {
+			return o.StringValue()
+		}
+	}
+	return ""
+}
@@ -0,0 +1,135 @@
+/*
+ * Copyright 2019 SAP SE or an SAP affiliate company. All rights reserved. This file is licensed under the Apache Software License, v. 2 except as noted otherwise in the LICENSE file
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ *
+ */
+
+package mock
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/gardener/external-dns-management/pkg/dns"
+	"github.com/gardener/external-dns-management/pkg/dns/provider"
+)
+
+// Faults configures the deterministic fault injection of a mock provider.
+type Faults struct {
+	// Latency is added to every request (duration string, e.g. "200ms").
+	Latency string `json:"latency,omitempty"`
+	// ThrottleEvery rejects every n-th request with a throttling error.
+	ThrottleEvery int `json:"throttleEvery,omitempty"`
+	// FailEvery fails every n-th change request of a batch, the other
+	// changes of the batch are executed.
+	FailEvery int `json:"failEvery,omitempty"`
+	// StaleReads is the number of record reads of a zone returning the
+	// records before the last change.
+	StaleReads int `json:"staleReads,omitempty"`
+}
+
+// faultInjector keeps the counters for the fault injection of a handler.
+type faultInjector struct {
+	lock     sync.Mutex
+	faults   Faults
+	latency  time.Duration
+	requests int
+	changes  int
+	stale    map[string]*staleZone
+}
+
+type staleZone struct {
+	dnssets dns.DNSSets
+	reads   int
+}
+
+func newFaultInjector(faults *Faults) (*faultInjector, error) {
+	if faults == nil {
+		return nil, nil
+	}
+	this := &faultInjector{faults: *faults, stale: map[string]*staleZone{}}
+	if faults.Latency != "" {
+		d, err := time.ParseDuration(faults.Latency)
+		if err != nil {
+			return nil, fmt.Errorf("invalid fault latency %q: %s", faults.Latency, err)
+		}
+		this.latency = d
+	}
+	return this, nil
+}
+
+// request is called for every request to the provider and returns
+// an error if the request should be throttled.
+func (this *faultInjector) request(what string) error {
+	if this == nil {
+		return nil
+	}
+	if this.latency > 0 {
+		time.Sleep(this.latency)
+	}
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	this.requests++
+	if this.faults.ThrottleEvery > 0 && this.requests%this.faults.ThrottleEvery == 0 {
+		return provider.NewThrottlingError(fmt.Errorf("injected throttling for %s (request %d)", what, this.requests))
+	}
+	return nil
+}
+
+// change returns an error if the next change request of a batch
+// should fail.
+func (this *faultInjector) change(r *provider.ChangeRequest) error {
+	if this == nil {
+		return nil
+	}
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	this.changes++
+	if this.faults.FailEvery > 0 && this.changes%this.faults.FailEvery == 0 {
+		return fmt.Errorf("injected failure for %s (change %d)", r, this.changes)
+	}
+	return nil
+}
+
+// changing remembers the actual records of a zone before it is changed
+// to serve stale reads.
+func (this *faultInjector) changing(zoneid string) {
+	if this == nil || this.faults.StaleReads <= 0 {
+		return
+	}
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	if s := this.stale[zoneid]; s != nil && s.reads > 0 {
+		// keep the oldest state until all stale reads are served
+		s.reads = this.faults.StaleReads
+		return
+	}
+	dnssets, _ := store.getDNSSets(zoneid)
+	this.stale[zoneid] = &staleZone{dnssets: dnssets, reads: this.faults.StaleReads}
+}
+
+// read returns the stale records of a zone, if there are stale reads left.
+func (this *faultInjector) read(zoneid string) dns.DNSSets {
+	if this == nil {
+		return nil
+	}
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	s := this.stale[zoneid]
+	if s == nil || s.reads <= 0 {
+		return nil
+	}
+	s.reads--
+	return s.dnssets.Clone()
+}
@@ -0,0 +1,158 @@
+/*
+ * Copyright 2019 SAP SE or an SAP affiliate company. All rights reserved. This file is licensed under the Apache Software License, v. 2 except as noted otherwise in the LICENSE file
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ *
+ */
+
+package mock
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/gardener/controller-manager-library/pkg/logger"
+
+	"github.com/gardener/external-dns-management/pkg/dns"
+	"github.com/gardener/external-dns-management/pkg/dns/metrics"
+	"github.com/gardener/external-dns-management/pkg/dns/provider"
+)
+
+// Config is the provider config of a mock provider.
+type Config struct {
+	// Name identifies the set of zones, providers with the same name
+	// share their zones. Default is "mock".
+	Name string `json:"name,omitempty"`
+	// Zones are the domains of the hosted zones offered by the provider.
+	Zones []string `json:"zones"`
+	// Faults optionally configures fault injection for tests.
+	Faults *Faults `json:"faults,omitempty"`
+}
+
+type Handler struct {
+	config provider.DNSHandlerConfig
+	mock   Config
+	zones  provider.DNSHostedZoneInfos
+	faults *faultInjector
+}
+
+var _ provider.DNSHandler = &Handler{}
+
+func NewHandler(logger logger.LogContext, config *provider.DNSHandlerConfig) (provider.DNSHandler, error) {
+	this := &Handler{
+		config: *config,
+	}
+	if config.Config == nil || len(config.Config.Raw) == 0 {
+		return nil, fmt.Errorf("providerConfig with mock zones required")
+	}
+	if err := json.Unmarshal(config.Config.Raw, &this.mock); err != nil {
+		return nil, fmt.Errorf("invalid providerConfig: %s", err)
+	}
+	if len(this.mock.Zones) == 0 {
+		return nil, fmt.Errorf("no zones configured for mock provider")
+	}
+	if this.mock.Name == "" {
+		this.mock.Name = "mock"
+	}
+	faults, err := newFaultInjector(this.mock.Faults)
+	if err != nil {
+		return nil, err
+	}
+	this.faults = faults
+	for _, d := range this.mock.Zones {
+		z := store.assure(this.mock.Name, dns.NormalizeHostname(d))
+		this.zones = append(this.zones, &provider.DNSHostedZoneInfo{Id: z.id, Domain: z.domain})
+	}
+	// the name server is configured by the operator, never by the
+	// provider config.
+	if addr := dnsServer(this.config.Context); addr != "" {
+		if err := startServer(logger, addr); err != nil {
+			return nil, err
+		}
+	}
+	return this, nil
+}
+
+func (this *Handler) GetZones() (provider.DNSHostedZoneInfos, error) {
+	metrics.AddRequests(TYPE_MOCK, "", metrics.M_LISTZONES, 1)
+	if err := this.faults.request(metrics.M_LISTZONES); err != nil {
+		metrics.AddError(TYPE_MOCK, "", metrics.M_LISTZONES, true)
+		return nil, err
+	}
+	return this.zones, nil
+}
+
+func (this *Handler) GetDNSSets(zoneid string) (dns.DNSSets, error) {
+	metrics.AddRequests(TYPE_MOCK, zoneid, metrics.M_LISTRECORDS, 1)
+	if err := this.faults.request(metrics.M_LISTRECORDS); err != nil {
+		metrics.AddError(TYPE_MOCK, zoneid, metrics.M_LISTRECORDS, true)
+		return nil, err
+	}
+	if dnssets := this.faults.read(zoneid); dnssets != nil {
+		return dnssets, nil
+	}
+	dnssets, ok := store.getDNSSets(zoneid)
+	if !ok {
+		return nil, fmt.Errorf("zone %q not found", zoneid)
+	}
+	return dnssets, nil
+}
+
+func (this *Handler) ExecuteRequests(logger logger.LogContext, zoneid string, reqs []*provider.ChangeRequest) error {
+	if this.config.DryRun {
+		logger.Infof("no changes in dryrun mode for %s", TYPE_MOCK)
+		return nil
+	}
+	metrics.AddRequests(TYPE_MOCK, zoneid, metrics.M_CHANGE, 1)
+	if err := this.faults.request(metrics.M_CHANGE); err != nil {
+		metrics.AddError(TYPE_MOCK, zoneid, metrics.M_CHANGE, true)
+		for _, r := range reqs {
+			if r.Done != nil {
+				r.Done.Failed(err)
+			}
+		}
+		return err
+	}
+	this.faults.changing(zoneid)
+	var failed error
+	for _, r := range reqs {
+		if err := this.faults.change(r); err != nil {
+			logger.Warnf("%s", err)
+			failed = err
+			if r.Done != nil {
+				r.Done.Failed(err)
+			}
+			continue
+		}
+		var name string
+		var rs *dns.RecordSet
+		ok := true
+		switch r.Action {
+		case provider.R_CREATE, provider.R_UPDATE:
+			name, rs = dns.MapToProvider(r.Type, r.Addition)
+			logger.Infof("%s %s record set %s[%s]: %s", r.Action, r.Type, name, zoneid, rs.RecordString())
+			ok = store.set(zoneid, name, rs)
+		case provider.R_DELETE:
+			name, rs = dns.MapToProvider(r.Type, r.Deletion)
+			logger.Infof("%s %s record set %s[%s]: %s", r.Action, r.Type, name, zoneid, rs.RecordString())
+			ok = store.delete(zoneid, name, rs.Type)
+		}
+		if r.Done != nil {
+			if ok {
+				r.Done.Succeeded()
+			} else {
+				r.Done.Failed(fmt.Errorf("zone %q not found", zoneid))
+			}
+		}
+	}
+	return failed
+}
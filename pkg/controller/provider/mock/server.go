@@ -0,0 +1,210 @@
+/*
+ * Copyright 2019 SAP SE or an SAP affiliate company. All rights reserved. This file is licensed under the Apache Software License, v. 2 except as noted otherwise in the LICENSE file
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ *
+ */
+
+package mock
+
+import (
+	"encoding/binary"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+
+	"github.com/gardener/controller-manager-library/pkg/logger"
+
+	"github.com/gardener/external-dns-management/pkg/dns"
+)
+
+// A minimal authoritative name server for the mock zones. It answers
+// single question UDP queries for A, AAAA, CNAME and TXT records, which is
+// sufficient for propagation checks and local tests.
+
+const (
+	qtypeA     = 1
+	qtypeCNAME = 5
+	qtypeTXT   = 16
+	qtypeAAAA  = 28
+	qclassIN   = 1
+
+	rcodeNoError  = 0
+	rcodeFormErr  = 1
+	rcodeNXDomain = 3
+	rcodeRefused  = 5
+)
+
+var qtypes = map[uint16]string{
+	qtypeA:     dns.RS_A,
+	qtypeCNAME: dns.RS_CNAME,
+	qtypeTXT:   dns.RS_TXT,
+	qtypeAAAA:  dns.RS_AAAA,
+}
+
+var servers = map[string]net.PacketConn{}
+var serverLock sync.Mutex
+
+// startServer starts the name server for the given address once.
+func startServer(logger logger.LogContext, addr string) error {
+	serverLock.Lock()
+	defer serverLock.Unlock()
+	if servers[addr] != nil {
+		return nil
+	}
+	conn, err := net.ListenPacket("udp", addr)
+	if err != nil {
+		return fmt.Errorf("cannot start mock dns server on %s: %s", addr, err)
+	}
+	logger.Infof("mock dns server listening on %s", addr)
+	servers[addr] = conn
+	go serve(conn)
+	return nil
+}
+
+func serve(conn net.PacketConn) {
+	buf := make([]byte, 512)
+	for {
+		n, addr, err := conn.ReadFrom(buf)
+		if err != nil {
+			return
+		}
+		if resp := answer(buf[:n]); resp != nil {
+			conn.WriteTo(resp, addr)
+		}
+	}
+}
+
+// answer builds the response message for a query message.
+func answer(query []byte) []byte {
+	if len(query) < 12 || query[2]&0x80 != 0 {
+		return nil
+	}
+	resp := make([]byte, 12, 512)
+	copy(resp, query[:4])
+	resp[2] = 0x84 | query[2]&0x79 // response, authoritative, opcode and rd from query
+	resp[3] = 0
+
+	name, end, ok := parseName(query, 12)
+	if !ok || binary.BigEndian.Uint16(query[4:6]) != 1 || end+4 > len(query) {
+		resp[3] = rcodeFormErr
+		return resp
+	}
+	qtype := binary.BigEndian.Uint16(query[end : end+2])
+	qclass := binary.BigEndian.Uint16(query[end+2 : end+4])
+	// echo the question section
+	binary.BigEndian.PutUint16(resp[4:6], 1)
+	resp = append(resp, query[12:end+4]...)
+
+	sets, domain := store.lookup(name)
+	switch {
+	case domain == "" || qclass != qclassIN:
+		resp[3] = rcodeRefused
+		return resp
+	case sets == nil:
+		resp[3] = rcodeNXDomain
+		return resp
+	}
+
+	rs := sets[qtypes[qtype]]
+	if rs == nil && qtype != qtypeCNAME {
+		if rs = sets[dns.RS_CNAME]; rs != nil {
+			qtype = qtypeCNAME
+		}
+	}
+	count := 0
+	if rs != nil {
+		for _, r := range rs.Records {
+			rdata, ok := encodeRData(qtype, r.Value)
+			if !ok {
+				continue
+			}
+			// name as pointer to the question
+			resp = append(resp, 0xc0, 12)
+			resp = appendUint16(resp, qtype)
+			resp = appendUint16(resp, qclassIN)
+			resp = append(resp, 0, 0, 0, 0)
+			binary.BigEndian.PutUint32(resp[len(resp)-4:], uint32(rs.TTL))
+			resp = appendUint16(resp, uint16(len(rdata)))
+			resp = append(resp, rdata...)
+			count++
+		}
+	}
+	binary.BigEndian.PutUint16(resp[6:8], uint16(count))
+	resp[3] = rcodeNoError
+	return resp
+}
+
+func appendUint16(b []byte, v uint16) []byte {
+	return append(b, byte(v>>8), byte(v))
+}
+
+// parseName parses an uncompressed domain name starting at the given
+// offset and returns the normalized name and the offset behind it.
+func parseName(msg []byte, offset int) (string, int, bool) {
+	labels := []string{}
+	for offset < len(msg) {
+		l := int(msg[offset])
+		offset++
+		if l == 0 {
+			return strings.ToLower(strings.Join(labels, ".")), offset, true
+		}
+		if l > 63 || offset+l > len(msg) {
+			return "", 0, false
+		}
+		labels = append(labels, string(msg[offset:offset+l]))
+		offset += l
+	}
+	return "", 0, false
+}
+
+func encodeName(name string) []byte {
+	b := []byte{}
+	for _, l := range strings.Split(dns.NormalizeHostname(name), ".") {
+		if l != "" {
+			b = append(b, byte(len(l)))
+			b = append(b, l...)
+		}
+	}
+	return append(b, 0)
+}
+
+func encodeRData(qtype uint16, value string) ([]byte, bool) {
+	switch qtype {
+	case qtypeA:
+		ip := net.ParseIP(value).To4()
+		return ip, ip != nil
+	case qtypeAAAA:
+		ip := net.ParseIP(value).To16()
+		return ip, ip != nil && ip.To4() == nil
+	case qtypeCNAME:
+		return encodeName(value), true
+	case qtypeTXT:
+		chunks, ok := dns.SplitText(value)
+		if !ok {
+			chunks = []string{value}
+		}
+		b := []byte{}
+		for _, c := range chunks {
+			for len(c) > dns.MAX_TEXT_CHUNK {
+				b = append(b, dns.MAX_TEXT_CHUNK)
+				b = append(b, c[:dns.MAX_TEXT_CHUNK]...)
+				c = c[dns.MAX_TEXT_CHUNK:]
+			}
+			b = append(b, byte(len(c)))
+			b = append(b, c...)
+		}
+		return b, true
+	}
+	return nil, false
+}
@@ -0,0 +1,140 @@
+/*
+ * Copyright 2019 SAP SE or an SAP affiliate company. All rights reserved. This file is licensed under the Apache Software License, v. 2 except as noted otherwise in the LICENSE file
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ *
+ */
+
+package mock
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/gardener/external-dns-management/pkg/dns"
+	dnsutils "github.com/gardener/external-dns-management/pkg/dns/utils"
+)
+
+// store keeps the hosted zones of all mock providers. It is shared by all
+// handlers, so the records survive the recreation of a handler and
+// providers with the same name share their zones.
+var store = &zoneStore{zones: map[string]*zone{}}
+
+type zoneStore struct {
+	lock  sync.RWMutex
+	zones map[string]*zone
+}
+
+// zone holds the record sets of a hosted zone in the provider
+// representation, indexed by dns name and record type.
+type zone struct {
+	id      string
+	domain  string
+	records map[string]dns.RecordSets
+}
+
+func zoneId(name, domain string) string {
+	return name + ":" + domain
+}
+
+func (this *zoneStore) assure(name, domain string) *zone {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	id := zoneId(name, domain)
+	z := this.zones[id]
+	if z == nil {
+		z = &zone{id: id, domain: domain, records: map[string]dns.RecordSets{}}
+		this.zones[id] = z
+	}
+	return z
+}
+
+// getDNSSets returns a copy of the record sets of a zone.
+func (this *zoneStore) getDNSSets(zoneid string) (dns.DNSSets, bool) {
+	this.lock.RLock()
+	defer this.lock.RUnlock()
+	z := this.zones[zoneid]
+	if z == nil {
+		return nil, false
+	}
+	dnssets := dns.DNSSets{}
+	for name, sets := range z.records {
+		for _, rs := range sets {
+			dnssets.AddRecordSetFromProvider(name, rs.Clone())
+		}
+	}
+	return dnssets, true
+}
+
+func (this *zoneStore) set(zoneid, name string, rs *dns.RecordSet) bool {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	z := this.zones[zoneid]
+	if z == nil {
+		return false
+	}
+	sets := z.records[name]
+	if sets == nil {
+		sets = dns.RecordSets{}
+		z.records[name] = sets
+	}
+	sets[rs.Type] = rs.Clone()
+	return true
+}
+
+func (this *zoneStore) delete(zoneid, name, rtype string) bool {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	z := this.zones[zoneid]
+	if z == nil {
+		return false
+	}
+	sets := z.records[name]
+	if sets != nil {
+		delete(sets, rtype)
+		if len(sets) == 0 {
+			delete(z.records, name)
+		}
+	}
+	return true
+}
+
+// lookup returns the record sets for a dns name together with the
+// domain of the zone containing it. Wildcard records are considered
+// if there are no records for the name itself.
+func (this *zoneStore) lookup(name string) (dns.RecordSets, string) {
+	this.lock.RLock()
+	defer this.lock.RUnlock()
+	var found *zone
+	for _, z := range this.zones {
+		if dnsutils.Match(name, z.domain) && (found == nil || len(z.domain) > len(found.domain)) {
+			found = z
+		}
+	}
+	if found == nil {
+		return nil, ""
+	}
+	sets := found.records[name]
+	if sets == nil {
+		if i := strings.Index(name, "."); i > 0 {
+			sets = found.records["*"+name[i:]]
+		}
+	}
+	if sets == nil {
+		return nil, found.domain
+	}
+	result := dns.RecordSets{}
+	for t, rs := range sets {
+		result[t] = rs.Clone()
+	}
+	return result, found.domain
+}
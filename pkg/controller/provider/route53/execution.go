@@ -17,15 +17,23 @@
 package route53
 
 import (
+	"time"
+
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
 	"github.com/aws/aws-sdk-go/service/route53"
 
 	"github.com/gardener/controller-manager-library/pkg/logger"
 
 	"github.com/gardener/external-dns-management/pkg/dns"
+	"github.com/gardener/external-dns-management/pkg/dns/metrics"
 	"github.com/gardener/external-dns-management/pkg/dns/provider"
 )
 
+// META_HEALTH_CHECK_ID is the provider metadata key for the id of the
+// Route53 health check associated with the records of an entry.
+const META_HEALTH_CHECK_ID = "route53/health-check-id"
+
 type Change struct {
 	*route53.Change
 	Done provider.DoneHandler
@@ -41,7 +49,11 @@ type Execution struct {
 }
 
 func NewExecution(logger logger.LogContext, h *Handler, zoneid string) *Execution {
-	return &Execution{LogContext: logger, handler: h, zoneid: zoneid, changes: map[string][]*Change{}, maxChangeCount: 20}
+	max := h.config.MaxChangeCount
+	if max <= 0 {
+		max = 20
+	}
+	return &Execution{LogContext: logger, handler: h, zoneid: zoneid, changes: map[string][]*Change{}, maxChangeCount: max}
 }
 
 func (this *Execution) addChange(action string, req *provider.ChangeRequest, dnsset *dns.DNSSet) {
@@ -60,6 +72,11 @@ func (this *Execution) addChange(action string, req *provider.ChangeRequest, dns
 
 	change.ResourceRecordSet.Type = aws.String(rset.Type)
 	change.ResourceRecordSet.TTL = aws.Int64(rset.TTL)
+	if req.Type != dns.RS_META {
+		if id := dnsset.GetMetadata()[META_HEALTH_CHECK_ID]; id != "" {
+			change.ResourceRecordSet.HealthCheckId = aws.String(id)
+		}
+	}
 	change.ResourceRecordSet.ResourceRecords = make([]*route53.ResourceRecord, len(rset.Records))
 	for i, r := range rset.Records {
 		change.ResourceRecordSet.ResourceRecords[i] = &route53.ResourceRecord{
@@ -89,8 +106,16 @@ func (this *Execution) submitChanges() error {
 			},
 		}
 
-		if _, err := this.handler.r53.ChangeResourceRecordSets(params); err != nil {
+		start := time.Now()
+		metrics.AddRequests(TYPE_AWS, this.zoneid, metrics.M_CHANGE, 1)
+		_, err := this.handler.r53.ChangeResourceRecordSets(params)
+		metrics.ObserveChangeBatch(TYPE_AWS, this.zoneid, start, len(changes))
+		if err != nil {
+			metrics.AddError(TYPE_AWS, this.zoneid, metrics.M_CHANGE, request.IsErrorThrottle(err))
 			this.Error(err)
+			if request.IsErrorThrottle(err) {
+				err = provider.NewThrottlingError(err)
+			}
 			for _, c := range changes {
 				if c.Done != nil {
 					c.Done.Failed(err)
@@ -117,7 +142,7 @@ func limitChangeSet(changesByName map[string][]*Change, max int) [][]*Change {
 	for _, changes := range changesByName {
 		for _, change := range changes {
 			if aws.StringValue(change.Change.Action) == route53.ChangeActionDelete {
-				batch = addLimited(change, batch, batches, max)
+				batch, batches = addLimited(change, batch, batches, max)
 			}
 		}
 	}
@@ -131,7 +156,7 @@ func limitChangeSet(changesByName map[string][]*Change, max int) [][]*Change {
 	for _, changes := range changesByName {
 		for _, change := range changes {
 			if aws.StringValue(change.Change.Action) != route53.ChangeActionDelete {
-				batch = addLimited(change, batch, batches, max)
+				batch, batches = addLimited(change, batch, batches, max)
 			}
 		}
 	}
@@ -142,12 +167,12 @@ func limitChangeSet(changesByName map[string][]*Change, max int) [][]*Change {
 	return batches
 }
 
-func addLimited(change *Change, batch []*Change, batches [][]*Change, max int) []*Change {
+func addLimited(change *Change, batch []*Change, batches [][]*Change, max int) ([]*Change, [][]*Change) {
 	if len(batch) >= max {
 		batches = append(batches, batch)
 		batch = make([]*Change, 0)
 	}
-	return append(batch, change)
+	return append(batch, change), batches
 }
 
 func mapChanges(changes []*Change) []*route53.Change {
@@ -24,9 +24,11 @@ import (
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/request"
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/route53"
 	"github.com/gardener/external-dns-management/pkg/dns"
+	"github.com/gardener/external-dns-management/pkg/dns/metrics"
 )
 
 type Handler struct {
@@ -37,6 +39,7 @@ type Handler struct {
 }
 
 var _ provider.DNSHandler = &Handler{}
+var _ provider.StreamingDNSHandler = &Handler{}
 
 func NewHandler(logger logger.LogContext, config *provider.DNSHandlerConfig) (provider.DNSHandler, error) {
 	this := &Handler{
@@ -60,6 +63,11 @@ func NewHandler(logger logger.LogContext, config *provider.DNSHandlerConfig) (pr
 	if err != nil {
 		return nil, err
 	}
+	if limiter := this.config.RateLimiter; limiter != nil {
+		sess.Handlers.Send.PushFront(func(r *request.Request) {
+			limiter.Accept()
+		})
+	}
 	this.sess = sess
 	this.r53 = route53.New(sess)
 	return this, nil
@@ -69,6 +77,7 @@ func (this *Handler) GetZones() (provider.DNSHostedZoneInfos, error) {
 	zones := provider.DNSHostedZoneInfos{}
 
 	aggr := func(resp *route53.ListHostedZonesOutput, lastPage bool) bool {
+		metrics.AddRequests(TYPE_AWS, "", metrics.M_LISTZONES, 1)
 		for _, zone := range resp.HostedZones {
 			id := strings.Split(aws.StringValue(zone.Id), "/")
 
@@ -76,6 +85,9 @@ func (this *Handler) GetZones() (provider.DNSHostedZoneInfos, error) {
 				Id:     id[len(id)-1],
 				Domain: dns.NormalizeHostname(aws.StringValue(zone.Name)),
 			}
+			if zone.Config != nil {
+				zoneinfo.Private = aws.BoolValue(zone.Config.PrivateZone)
+			}
 			zones = append(zones, zoneinfo)
 		}
 		return true
@@ -83,6 +95,7 @@ func (this *Handler) GetZones() (provider.DNSHostedZoneInfos, error) {
 
 	err := this.r53.ListHostedZonesPages(&route53.ListHostedZonesInput{}, aggr)
 	if err != nil {
+		metrics.AddError(TYPE_AWS, "", metrics.M_LISTZONES, request.IsErrorThrottle(err))
 		return nil, err
 	}
 	return zones, nil
@@ -90,31 +103,76 @@ func (this *Handler) GetZones() (provider.DNSHostedZoneInfos, error) {
 
 func (this *Handler) GetDNSSets(zoneid string) (dns.DNSSets, error) {
 	dnssets := dns.DNSSets{}
+	if err := this.VisitDNSSets(zoneid, dnssets.AddRecordSetFromProvider); err != nil {
+		return nil, err
+	}
+	return dnssets, nil
+}
 
+func (this *Handler) VisitDNSSets(zoneid string, visitor func(dnsname string, rs *dns.RecordSet)) error {
 	inp := (&route53.ListResourceRecordSetsInput{}).SetHostedZoneId(zoneid)
 	aggr := func(resp *route53.ListResourceRecordSetsOutput, lastPage bool) (shouldContinue bool) {
+		metrics.AddRequests(TYPE_AWS, zoneid, metrics.M_LISTRECORDS, 1)
 		for _, r := range resp.ResourceRecordSets {
-			rtype := aws.StringValue(r.Type)
-			if !dns.SupportedRecordType(rtype) {
-				continue
-			}
-
-			rs := dns.NewRecordSet(rtype, aws.Int64Value(r.TTL), nil)
-			for _, rr := range r.ResourceRecords {
-				rs.Add(&dns.Record{Value: aws.StringValue(rr.Value)})
+			if rs := mapFromRecordSet(r); rs != nil {
+				visitor(aws.StringValue(r.Name), rs)
 			}
-
-			dnssets.AddRecordSetFromProvider(aws.StringValue(r.Name), rs)
 		}
 		return true
 	}
 
 	if err := this.r53.ListResourceRecordSetsPages(inp, aggr); err != nil {
-		return nil, err
+		metrics.AddError(TYPE_AWS, zoneid, metrics.M_LISTRECORDS, request.IsErrorThrottle(err))
+		return err
+	}
+	return nil
+}
+
+func (this *Handler) GetDNSSetsForName(zoneid string, dnsname string) (dns.DNSSets, error) {
+	dnssets := dns.DNSSets{}
+	for _, name := range append([]string{dnsname}, dns.MetaRecordNames(dnsname)...) {
+		inp := (&route53.ListResourceRecordSetsInput{}).SetHostedZoneId(zoneid).
+			SetStartRecordName(dns.AlignHostname(name)).SetMaxItems("10")
+		metrics.AddRequests(TYPE_AWS, zoneid, metrics.M_LISTRECORDS, 1)
+		resp, err := this.r53.ListResourceRecordSets(inp)
+		if err != nil {
+			metrics.AddError(TYPE_AWS, zoneid, metrics.M_LISTRECORDS, request.IsErrorThrottle(err))
+			return nil, err
+		}
+		for _, r := range resp.ResourceRecordSets {
+			if recordName(r) != name {
+				break
+			}
+			if rs := mapFromRecordSet(r); rs != nil {
+				dnssets.AddRecordSetFromProvider(aws.StringValue(r.Name), rs)
+			}
+		}
 	}
 	return dnssets, nil
 }
 
+// recordName returns the normalized dns name of a record set. Route53
+// escapes the wildcard character as \052 in all returned names.
+func recordName(r *route53.ResourceRecordSet) string {
+	return dns.NormalizeHostname(strings.Replace(aws.StringValue(r.Name), "\\052", "*", -1))
+}
+
+func mapFromRecordSet(r *route53.ResourceRecordSet) *dns.RecordSet {
+	rtype := aws.StringValue(r.Type)
+	if !dns.SupportedRecordType(rtype) {
+		return nil
+	}
+	rs := dns.NewRecordSet(rtype, aws.Int64Value(r.TTL), nil)
+	for _, rr := range r.ResourceRecords {
+		value := aws.StringValue(rr.Value)
+		if rtype == dns.RS_TXT {
+			value = dns.NormalizeText(value)
+		}
+		rs.Add(&dns.Record{Value: value})
+	}
+	return rs
+}
+
 func (this *Handler) ExecuteRequests(logger logger.LogContext, zoneid string, reqs []*provider.ChangeRequest) error {
 	exec := NewExecution(logger, this, zoneid)
 
@@ -37,6 +37,26 @@ var DNSProviderCRD = apiextensions.CreateCRDObject(api.GroupName, api.Version, a
 		JSONPath:    ".status.state",
 	})
 
+var DNSReportCRD = apiextensions.CreateCRDObject(api.GroupName, api.Version, api.DNSReportKind, api.DNSReportPlural, "dnsr", false,
+	v1beta1.CustomResourceColumnDefinition{
+		Name:        "TYPE",
+		Description: "Provider type",
+		Type:        "string",
+		JSONPath:    ".status.providerType",
+	},
+	v1beta1.CustomResourceColumnDefinition{
+		Name:        "HEALTHY",
+		Description: "All providers and entries are ready",
+		Type:        "boolean",
+		JSONPath:    ".status.healthy",
+	},
+	v1beta1.CustomResourceColumnDefinition{
+		Name:        "STALE",
+		Description: "Number of stale entries",
+		Type:        "integer",
+		JSONPath:    ".status.staleEntries",
+	})
+
 var DNSEntryCRD = apiextensions.CreateCRDObject(api.GroupName, api.Version, api.DNSEntryKind, api.DNSEntryPlural, "dnse", true,
 	v1beta1.CustomResourceColumnDefinition{
 		Name:        "DNS",
@@ -49,4 +69,10 @@ var DNSEntryCRD = apiextensions.CreateCRDObject(api.GroupName, api.Version, api.
 		Description: "Status of DNS entry in cloud provider",
 		Type:        "string",
 		JSONPath:    ".status.state",
+	},
+	v1beta1.CustomResourceColumnDefinition{
+		Name:        "PROPAGATION",
+		Description: "Propagation of the DNS records",
+		Type:        "string",
+		JSONPath:    ".status.propagation.state",
 	})
@@ -16,7 +16,11 @@
 
 package dns
 
-import "github.com/gardener/controller-manager-library/pkg/utils"
+import (
+	"strings"
+
+	"github.com/gardener/controller-manager-library/pkg/utils"
+)
 
 ////////////////////////////////////////////////////////////////////////////////
 // A DNSSet contains Record sets for an DNS name. The name is given without
@@ -48,6 +52,10 @@ import "github.com/gardener/controller-manager-library/pkg/utils"
 // MapToProvider. These methods can be called by the provider when reading
 // or writing a record set, respectivly. The map the given set to
 // an effective set and dns name for the desired purpose.
+//
+// Alternatively the owner can be stored in the TXT registry format of
+// kubernetes-sigs/external-dns (see Registry). Meta data in this format
+// is always recognized when reading record sets.
 
 type DNSSets map[string]*DNSSet
 
@@ -67,10 +75,26 @@ func (dnssets DNSSets) AddRecordSet(name string, rs *RecordSet) {
 	dnsset.Sets[rs.Type] = rs
 }
 
+func (dnssets DNSSets) Clone() DNSSets {
+	clone := DNSSets{}
+	for n, s := range dnssets {
+		clone[n] = s.Clone()
+	}
+	return clone
+}
+
 const (
 	ATTR_OWNER  = "owner"
 	ATTR_PREFIX = "prefix"
 	ATTR_CNAMES = "cnames"
+
+	// ATTR_HERITAGE marks meta data stored in the external-dns
+	// registry format.
+	ATTR_HERITAGE = "heritage"
+
+	// ATTR_METADATA_PREFIX is the prefix of the attributes for the
+	// provider specific metadata of the records.
+	ATTR_METADATA_PREFIX = "metadata."
 )
 
 type DNSSet struct {
@@ -78,6 +102,14 @@ type DNSSet struct {
 	Sets RecordSets
 }
 
+func (this *DNSSet) Clone() *DNSSet {
+	set := NewDNSSet(this.Name)
+	for t, rs := range this.Sets {
+		set.Sets[t] = rs.Clone()
+	}
+	return set
+}
+
 func (this *DNSSet) GetAttr(name string) string {
 	meta := this.Sets[RS_META]
 	if meta != nil {
@@ -96,6 +128,16 @@ func (this *DNSSet) SetAttr(name string, value string) {
 	}
 }
 
+// InitRegistry prepares the meta data of a new set to be stored
+// in the configured registry format.
+func (this *DNSSet) InitRegistry() {
+	if Registry == REGISTRY_EXTERNAL_DNS {
+		this.SetAttr(ATTR_HERITAGE, HERITAGE_EXTERNAL_DNS)
+	} else {
+		this.SetAttr(ATTR_PREFIX, TxtPrefix)
+	}
+}
+
 func (this *DNSSet) IsOwnedBy(owners utils.StringSet) bool {
 	o := this.GetAttr(ATTR_OWNER)
 	return o != "" && owners.Contains(o)
@@ -115,6 +157,28 @@ func (this *DNSSet) SetOwner(ownerid string) *DNSSet {
 	return this
 }
 
+// GetMetadata returns the provider specific metadata of the records.
+func (this *DNSSet) GetMetadata() map[string]string {
+	result := map[string]string{}
+	if meta := this.Sets[RS_META]; meta != nil {
+		for _, r := range meta.Records {
+			if !strings.HasPrefix(r.Value, "\""+ATTR_METADATA_PREFIX) {
+				continue
+			}
+			kv := strings.SplitN(strings.Trim(r.Value, "\""), "=", 2)
+			if len(kv) == 2 {
+				result[kv[0][len(ATTR_METADATA_PREFIX):]] = kv[1]
+			}
+		}
+	}
+	return result
+}
+
+// SetMetadata sets a provider specific metadata attribute of the records.
+func (this *DNSSet) SetMetadata(key, value string) {
+	this.SetAttr(ATTR_METADATA_PREFIX+key, value)
+}
+
 func (this *DNSSet) SetRecordSet(rtype string, ttl int64, values ...string) {
 	records := make([]*Record, len(values))
 	for i, r := range values {
@@ -0,0 +1,251 @@
+/*
+ * Copyright 2019 SAP SE or an SAP affiliate company. All rights reserved. This file is licensed under the Apache Software License, v. 2 except as noted otherwise in the LICENSE file
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ *
+ */
+
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+	"unsafe"
+
+	"github.com/ghodss/yaml"
+	"github.com/sirupsen/logrus"
+
+	"github.com/gardener/controller-manager-library/pkg/controllermanager/config"
+	"github.com/gardener/controller-manager-library/pkg/controllermanager/controller"
+	"github.com/gardener/controller-manager-library/pkg/logger"
+)
+
+const OPT_LOG_FORMAT = "log-format"
+const OPT_LOG_CONFIG = "log-config"
+
+const FORMAT_TEXT = "text"
+const FORMAT_JSON = "json"
+
+// Settings describes the logging configuration. It is read from the
+// file given by option --log-config, typically a mounted config map,
+// and reloaded whenever the file changes.
+type Settings struct {
+	Format string `json:"format,omitempty"`
+	Level  string `json:"level,omitempty"`
+	// Levels maps controller or provider names to a log level
+	// overriding the global one.
+	Levels map[string]string `json:"levels,omitempty"`
+}
+
+func init() {
+	config.RegisterExtension(func(cfg *config.Config) {
+		opt, _ := cfg.AddStringOption(OPT_LOG_FORMAT)
+		opt.Description = "log output format (text or json)"
+		opt, _ = cfg.AddStringOption(OPT_LOG_CONFIG)
+		opt.Description = "file with logging settings (format, level and per controller levels), reloaded on change"
+	})
+}
+
+var once sync.Once
+
+// Configure applies the logging options of the controller manager. It is
+// called by all dns controllers, but evaluated only once.
+func Configure(c controller.Interface) {
+	once.Do(func() {
+		cfg := config.Get(c.GetContext())
+		if cfg == nil {
+			return
+		}
+		settings := &Settings{Level: cfg.LogLevel}
+		if o := cfg.GetOption(OPT_LOG_FORMAT); o != nil {
+			settings.Format = o.StringValue()
+		}
+		if err := apply(settings); err != nil {
+			logger.Errorf("invalid logging settings: %s", err)
+		}
+		if o := cfg.GetOption(OPT_LOG_CONFIG); o != nil && o.StringValue() != "" {
+			go watch(c.GetContext(), o.StringValue(), *settings)
+		}
+	})
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// watch periodically checks the settings file and applies its content
+// on top of the settings given by command line options.
+func watch(ctx context.Context, path string, defaults Settings) {
+	var last time.Time
+	for {
+		fi, err := os.Stat(path)
+		if err == nil && fi.ModTime() != last {
+			last = fi.ModTime()
+			settings, err := read(path, defaults)
+			if err == nil {
+				err = apply(settings)
+			}
+			if err != nil {
+				logger.Errorf("invalid logging settings in %q: %s", path, err)
+			} else {
+				logger.Infof("logging settings updated from %q", path)
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(10 * time.Second):
+		}
+	}
+}
+
+func read(path string, defaults Settings) (*Settings, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	settings := &Settings{}
+	if err := yaml.Unmarshal(data, settings); err != nil {
+		return nil, err
+	}
+	if settings.Format == "" {
+		settings.Format = defaults.Format
+	}
+	if settings.Level == "" {
+		settings.Level = defaults.Level
+	}
+	return settings, nil
+}
+
+func apply(settings *Settings) error {
+	level := logrus.InfoLevel
+	if settings.Level != "" {
+		l, err := logrus.ParseLevel(settings.Level)
+		if err != nil {
+			return err
+		}
+		level = l
+	}
+	max := level
+	levels := map[string]logrus.Level{}
+	for n, s := range settings.Levels {
+		l, err := logrus.ParseLevel(s)
+		if err != nil {
+			return fmt.Errorf("level for %q: %s", n, err)
+		}
+		levels[n] = l
+		if l > max {
+			max = l
+		}
+	}
+
+	var base logrus.Formatter
+	switch settings.Format {
+	case "", FORMAT_TEXT:
+		base = &logrus.TextFormatter{DisableColors: true}
+	case FORMAT_JSON:
+		base = &logrus.JSONFormatter{}
+	default:
+		return fmt.Errorf("invalid log format %q", settings.Format)
+	}
+
+	log, err := libraryLogger()
+	if err != nil {
+		return err
+	}
+	log.SetFormatter(&formatter{
+		base:   base,
+		json:   settings.Format == FORMAT_JSON,
+		level:  level,
+		levels: levels,
+	})
+	log.SetLevel(max)
+	logrus.SetLevel(max)
+	return nil
+}
+
+// libraryLogger returns the logrus logger used by the log contexts of the
+// controller manager library. The vendored library does not expose it, so
+// it is taken from the entry of a new log context. The field is checked
+// before it is accessed, so a changed library yields an error instead of
+// a crash.
+func libraryLogger() (*logrus.Logger, error) {
+	v := reflect.ValueOf(logger.New())
+	if v.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("unsupported log context %s", v.Type())
+	}
+	f := v.FieldByName("entry")
+	if !f.IsValid() || f.Type() != reflect.TypeOf((*logrus.Entry)(nil)) || f.IsNil() {
+		return nil, fmt.Errorf("unsupported log context %s", v.Type())
+	}
+	return (*logrus.Entry)(unsafe.Pointer(f.Pointer())).Logger, nil
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// formatter filters the log entries according to the log level configured
+// for the log context they are written for, and adds the context names as
+// fields for structured output.
+//
+// Log contexts prefix the message by their names ("<name>: "). The most
+// specific name with a configured level determines the level for an entry.
+type formatter struct {
+	base   logrus.Formatter
+	json   bool
+	level  logrus.Level
+	levels map[string]logrus.Level
+}
+
+func (this *formatter) Format(entry *logrus.Entry) ([]byte, error) {
+	names, msg := split(entry.Message)
+	level := this.level
+	for _, n := range names {
+		if l, ok := this.levels[n]; ok {
+			level = l
+		}
+	}
+	if entry.Level > level {
+		return nil, nil
+	}
+	if this.json && len(names) > 0 {
+		e := *entry
+		e.Data = logrus.Fields{}
+		for k, v := range entry.Data {
+			e.Data[k] = v
+		}
+		e.Data["controller"] = names[0]
+		if len(names) > 1 {
+			e.Data["context"] = strings.Join(names[1:], "/")
+		}
+		e.Message = msg
+		return this.base.Format(&e)
+	}
+	return this.base.Format(entry)
+}
+
+// split separates the log context names from the message. Only segments
+// without blanks are considered to be context names.
+func split(msg string) ([]string, string) {
+	var names []string
+	for {
+		i := strings.Index(msg, ": ")
+		if i <= 0 || strings.ContainsAny(msg[:i], " \t\n") {
+			return names, msg
+		}
+		names = append(names, msg[:i])
+		msg = msg[i+2:]
+	}
+}
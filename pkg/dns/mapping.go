@@ -17,6 +17,7 @@
 package dns
 
 import (
+	"fmt"
 	"strings"
 )
 
@@ -26,6 +27,32 @@ import (
 
 var TxtPrefix = "comment-"
 
+////////////////////////////////////////////////////////////////////////////////
+// Registry formats for the meta data
+////////////////////////////////////////////////////////////////////////////////
+
+// REGISTRY_DEFAULT stores the meta data as attribute records for a
+// separate dns name composed of the TxtPrefix and the original name.
+const REGISTRY_DEFAULT = "default"
+
+// REGISTRY_EXTERNAL_DNS stores the owner in the TXT registry format
+// used by kubernetes-sigs/external-dns.
+const REGISTRY_EXTERNAL_DNS = "external-dns"
+
+// Registry is the format used to store the meta data for new dns names.
+// Existing meta data is always read in both formats and kept in its
+// actual format until the owner is changed.
+var Registry = REGISTRY_DEFAULT
+
+// ExternalDNSPrefix is the prefix used for the names of the TXT records
+// in the external-dns format (option --txt-prefix of external-dns).
+var ExternalDNSPrefix = ""
+
+const HERITAGE_EXTERNAL_DNS = "external-dns"
+
+const externalDNSHeritage = "heritage=" + HERITAGE_EXTERNAL_DNS
+const externalDNSOwner = "external-dns/owner="
+
 func AlignHostname(host string) string {
 	if strings.HasSuffix(host, ".") {
 		return host
@@ -43,10 +70,30 @@ func NormalizeHostname(host string) string {
 	return host
 }
 
+// MetaRecordNames returns the additional dns names used by the provider
+// to store the meta data for a dns name for the supported registry formats.
+func MetaRecordNames(name string) []string {
+	names := []string{prefixedName(TxtPrefix, name)}
+	if ExternalDNSPrefix != "" && ExternalDNSPrefix != TxtPrefix {
+		names = append(names, prefixedName(ExternalDNSPrefix, name))
+	}
+	return names
+}
+
+func prefixedName(prefix, name string) string {
+	if strings.HasPrefix(name, "*.") {
+		return "*." + prefix + name[2:]
+	}
+	return prefix + name
+}
+
 func MapToProvider(rtype string, dnsset *DNSSet) (string, *RecordSet) {
 	name := dnsset.Name
 	rs := dnsset.Sets[rtype]
 	if rtype == RS_META {
+		if dnsset.GetAttr(ATTR_HERITAGE) == HERITAGE_EXTERNAL_DNS {
+			return prefixedName(ExternalDNSPrefix, name), mapToExternalDNS(rs)
+		}
 		prefix := dnsset.GetAttr(ATTR_PREFIX)
 		if prefix == "" {
 			prefix = TxtPrefix
@@ -66,6 +113,9 @@ func MapToProvider(rtype string, dnsset *DNSSet) (string, *RecordSet) {
 
 func MapFromProvider(dns string, rs *RecordSet) (string, *RecordSet) {
 	if rs.Type == RS_TXT {
+		if owner, ok := getExternalDNSOwner(rs); ok {
+			return mapFromExternalDNS(dns, rs, owner)
+		}
 		prefix := rs.GetAttr(ATTR_PREFIX)
 		if prefix != "" {
 			add := ""
@@ -84,3 +134,45 @@ func MapFromProvider(dns string, rs *RecordSet) (string, *RecordSet) {
 	}
 	return dns, rs
 }
+
+////////////////////////////////////////////////////////////////////////////////
+// external-dns TXT registry
+////////////////////////////////////////////////////////////////////////////////
+
+// getExternalDNSOwner returns the owner of a TXT record set
+// in the external-dns registry format.
+func getExternalDNSOwner(rs *RecordSet) (string, bool) {
+	if len(rs.Records) != 1 {
+		return "", false
+	}
+	fields := strings.Split(strings.Trim(rs.Records[0].Value, "\""), ",")
+	if fields[0] != externalDNSHeritage {
+		return "", false
+	}
+	for _, f := range fields[1:] {
+		if strings.HasPrefix(f, externalDNSOwner) {
+			return f[len(externalDNSOwner):], true
+		}
+	}
+	return "", true
+}
+
+func mapFromExternalDNS(dns string, rs *RecordSet, owner string) (string, *RecordSet) {
+	add := ""
+	if strings.HasPrefix(dns, "*.") {
+		add = "*."
+		dns = dns[2:]
+	}
+	if !strings.HasPrefix(dns, ExternalDNSPrefix) {
+		return add + dns, rs
+	}
+	meta := NewRecordSet(RS_META, rs.TTL, nil)
+	meta.SetAttr(ATTR_HERITAGE, HERITAGE_EXTERNAL_DNS)
+	meta.SetAttr(ATTR_OWNER, owner)
+	return add + dns[len(ExternalDNSPrefix):], meta
+}
+
+func mapToExternalDNS(meta *RecordSet) *RecordSet {
+	value := fmt.Sprintf("\"%s,%s%s\"", externalDNSHeritage, externalDNSOwner, meta.GetAttr(ATTR_OWNER))
+	return NewRecordSet(RS_TXT, meta.TTL, []*Record{{Value: value}})
+}
@@ -0,0 +1,162 @@
+/*
+ * Copyright 2019 SAP SE or an SAP affiliate company. All rights reserved. This file is licensed under the Apache Software License, v. 2 except as noted otherwise in the LICENSE file
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ *
+ */
+
+package metrics
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gardener/controller-manager-library/pkg/server"
+)
+
+func init() {
+	server.Register("/metrics", Handler)
+}
+
+// Handler serves all registered metrics in the prometheus text format,
+// or in the OpenMetrics format including exemplars, if it is accepted
+// by the client.
+func Handler(w http.ResponseWriter, r *http.Request) {
+	if strings.Contains(r.Header.Get("Accept"), "application/openmetrics-text") {
+		w.Header().Set("Content-Type", "application/openmetrics-text; version=1.0.0; charset=utf-8")
+		defaultRegistry.write(w, true)
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	defaultRegistry.write(w, false)
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// Provider metrics
+////////////////////////////////////////////////////////////////////////////////
+
+const (
+	M_LISTZONES   = "list_zones"
+	M_LISTRECORDS = "list_records"
+	M_CHANGE      = "change_records"
+	M_LISTCHANGES = "list_changes"
+)
+
+var (
+	requests = NewCounterVec("dns_provider_requests_total",
+		"number of requests sent to the provider API", "provider_type", "zone", "request")
+	failures = NewCounterVec("dns_provider_request_errors_total",
+		"number of failed requests to the provider API", "provider_type", "zone", "request")
+	throttled = NewCounterVec("dns_provider_request_throttled_total",
+		"number of requests rejected by the provider API because of rate limiting", "provider_type", "zone", "request")
+	batches = NewHistogramVec("dns_provider_change_batch_duration_seconds",
+		"duration of change batch requests", nil, "provider_type", "zone")
+	batchsizes = NewHistogramVec("dns_provider_change_batch_size",
+		"number of changes per change batch", []float64{1, 2, 5, 10, 20, 50, 100}, "provider_type", "zone")
+	zonesactive = NewGaugeVec("dns_zone_reconciles_active",
+		"number of running zone reconcilations", "provider")
+	zoneswaiting = NewGaugeVec("dns_zone_reconciles_waiting",
+		"number of zones waiting for a free worker of the provider", "provider")
+	zonequeue = NewGaugeVec("dns_zone_reconcile_queue_depth",
+		"number of pending zone reconcilations", "controller")
+	retries = NewCounterVec("dns_zone_reconcile_retries_total",
+		"number of retried zone reconcilations", "provider_type", "zone", "reason")
+	backoff = NewGaugeVec("dns_zone_reconcile_backoff_seconds",
+		"actual delay before the next retry of a failed zone reconcilation", "provider_type", "zone")
+	zoneduration = NewHistogramVec("dns_zone_reconcile_duration_seconds",
+		"duration of zone reconcilations", nil, "provider_type", "outcome")
+	entryduration = NewHistogramVec("dns_entry_reconcile_duration_seconds",
+		"duration from the detection of an entry change until its records are applied",
+		[]float64{1, 2.5, 5, 10, 30, 60, 120, 300, 600, 1800}, "provider_type", "outcome")
+	entryfailures = NewCounterVec("dns_entry_reconcile_failures_total",
+		"number of failed attempts to apply an entry change", "provider_type", "outcome")
+	cache = NewCounterVec("dns_zone_cache_requests_total",
+		"number of zone state requests served from cache (hit) or provider (miss)", "provider_type", "zone", "result")
+)
+
+// AddRequests counts requests of a dedicated kind sent to a provider API.
+// For zone independent requests the zone is empty.
+func AddRequests(ptype, zone, request string, n int) {
+	requests.Add(float64(n), ptype, zone, request)
+}
+
+// AddError counts a failed request. Throttled requests are counted
+// additionally as throttled.
+func AddError(ptype, zone, request string, throttle bool) {
+	failures.Inc(ptype, zone, request)
+	if throttle {
+		throttled.Inc(ptype, zone, request)
+	}
+}
+
+// ObserveChangeBatch records the duration and size of a change request.
+func ObserveChangeBatch(ptype, zone string, start time.Time, size int) {
+	batches.ObserveDuration(start, ptype, zone)
+	batchsizes.Observe(float64(size), ptype, zone)
+}
+
+// AddZoneCacheAccess counts accesses to the zone state.
+func AddZoneCacheAccess(ptype, zone string, hit bool) {
+	if hit {
+		cache.Inc(ptype, zone, "hit")
+	} else {
+		cache.Inc(ptype, zone, "miss")
+	}
+}
+
+// SetZoneReconciles reports the running and waiting zone reconcilations
+// of a provider.
+func SetZoneReconciles(provider string, active, waiting int) {
+	zonesactive.Set(float64(active), provider)
+	zoneswaiting.Set(float64(waiting), provider)
+}
+
+// SetZoneQueueDepth reports the length of the work queue for zone
+// reconcilations of a controller.
+func SetZoneQueueDepth(controller string, n int) {
+	zonequeue.Set(float64(n), controller)
+}
+
+// AddZoneRetry counts a failed zone reconcilation scheduled for retry.
+func AddZoneRetry(ptype, zone string, throttle bool) {
+	if throttle {
+		retries.Inc(ptype, zone, "throttled")
+	} else {
+		retries.Inc(ptype, zone, "error")
+	}
+}
+
+// SetZoneBackoff reports the delay before the next retry of a zone
+// reconcilation (0 after a successful reconcilation).
+func SetZoneBackoff(ptype, zone string, d time.Duration) {
+	backoff.Set(d.Seconds(), ptype, zone)
+}
+
+// ObserveZoneReconcile records the duration of a zone reconcilation by
+// its outcome. The trace id (if not empty) is kept as exemplar.
+func ObserveZoneReconcile(ptype, outcome string, start time.Time, traceid string) {
+	zoneduration.ObserveWithExemplar(time.Now().Sub(start).Seconds(), traceid, ptype, outcome)
+}
+
+// ObserveEntryReconcile records the duration from the detection of an
+// entry change until its records are applied (or the entry is invalid).
+// The trace id (if not empty) is kept as exemplar.
+func ObserveEntryReconcile(ptype, outcome string, start time.Time, traceid string) {
+	entryduration.ObserveWithExemplar(time.Now().Sub(start).Seconds(), traceid, ptype, outcome)
+}
+
+// AddEntryReconcileFailure counts a failed attempt to apply an entry
+// change, which is retried.
+func AddEntryReconcileFailure(ptype, outcome string) {
+	entryfailures.Inc(ptype, outcome)
+}
@@ -0,0 +1,300 @@
+/*
+ * Copyright 2019 SAP SE or an SAP affiliate company. All rights reserved. This file is licensed under the Apache Software License, v. 2 except as noted otherwise in the LICENSE file
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ *
+ */
+
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// A minimal metrics registry rendering the prometheus text exposition format
+// or the OpenMetrics format, which additionally contains the exemplars of
+// histograms.
+////////////////////////////////////////////////////////////////////////////////
+
+type metric interface {
+	Name() string
+	write(w io.Writer, openmetrics bool)
+}
+
+type registry struct {
+	lock    sync.Mutex
+	metrics []metric
+}
+
+var defaultRegistry = &registry{}
+
+func (this *registry) register(m metric) {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	this.metrics = append(this.metrics, m)
+}
+
+func (this *registry) write(w io.Writer, openmetrics bool) {
+	this.lock.Lock()
+	list := append([]metric{}, this.metrics...)
+	this.lock.Unlock()
+	sort.Slice(list, func(i, j int) bool { return list[i].Name() < list[j].Name() })
+	for _, m := range list {
+		m.write(w, openmetrics)
+	}
+	if openmetrics {
+		fmt.Fprintf(w, "# EOF\n")
+	}
+}
+
+type desc struct {
+	name   string
+	help   string
+	labels []string
+}
+
+func (this *desc) Name() string {
+	return this.name
+}
+
+func (this *desc) key(values []string) string {
+	if len(values) != len(this.labels) {
+		panic(fmt.Sprintf("metric %s requires %d label values, but got %d", this.name, len(this.labels), len(values)))
+	}
+	return strings.Join(values, "\x00")
+}
+
+func (this *desc) labelString(key string, extra ...string) string {
+	pairs := []string{}
+	if len(this.labels) > 0 {
+		for i, v := range strings.Split(key, "\x00") {
+			pairs = append(pairs, fmt.Sprintf("%s=%q", this.labels[i], v))
+		}
+	}
+	for i := 0; i+1 < len(extra); i += 2 {
+		pairs = append(pairs, fmt.Sprintf("%s=%q", extra[i], extra[i+1]))
+	}
+	if len(pairs) == 0 {
+		return ""
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+func (this *desc) header(w io.Writer, kind string, openmetrics bool) {
+	name := this.name
+	if openmetrics && kind == "counter" {
+		// OpenMetrics names the counter family without the suffix
+		name = strings.TrimSuffix(name, "_total")
+	}
+	fmt.Fprintf(w, "# HELP %s %s\n", name, this.help)
+	fmt.Fprintf(w, "# TYPE %s %s\n", name, kind)
+}
+
+func sortedKeys(m map[string]float64) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// Counter and Gauge vectors
+
+type valueVec struct {
+	desc
+	kind   string
+	lock   sync.Mutex
+	values map[string]float64
+}
+
+type CounterVec struct {
+	valueVec
+}
+
+type GaugeVec struct {
+	valueVec
+}
+
+func NewCounterVec(name, help string, labels ...string) *CounterVec {
+	c := &CounterVec{valueVec{desc: desc{name, help, labels}, kind: "counter", values: map[string]float64{}}}
+	defaultRegistry.register(c)
+	return c
+}
+
+func NewGaugeVec(name, help string, labels ...string) *GaugeVec {
+	g := &GaugeVec{valueVec{desc: desc{name, help, labels}, kind: "gauge", values: map[string]float64{}}}
+	defaultRegistry.register(g)
+	return g
+}
+
+func (this *CounterVec) Add(v float64, labels ...string) {
+	if v < 0 {
+		panic(fmt.Sprintf("counter %s cannot be decreased", this.name))
+	}
+	this.add(v, labels...)
+}
+
+func (this *CounterVec) Inc(labels ...string) {
+	this.add(1, labels...)
+}
+
+func (this *GaugeVec) Set(v float64, labels ...string) {
+	key := this.key(labels)
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	this.values[key] = v
+}
+
+func (this *GaugeVec) Add(v float64, labels ...string) {
+	this.add(v, labels...)
+}
+
+func (this *GaugeVec) Delete(labels ...string) {
+	key := this.key(labels)
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	delete(this.values, key)
+}
+
+func (this *valueVec) add(v float64, labels ...string) {
+	key := this.key(labels)
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	this.values[key] += v
+}
+
+func (this *valueVec) write(w io.Writer, openmetrics bool) {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	this.header(w, this.kind, openmetrics)
+	for _, k := range sortedKeys(this.values) {
+		fmt.Fprintf(w, "%s%s %s\n", this.name, this.labelString(k), formatFloat(this.values[k]))
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// Histogram vector
+
+var DefaultBuckets = []float64{.05, .1, .25, .5, 1, 2.5, 5, 10, 30, 60}
+
+type histogram struct {
+	counts []uint64
+	count  uint64
+	sum    float64
+	// exemplars are the last exemplars observed for the buckets,
+	// the last one is used for +Inf.
+	exemplars []*exemplar
+}
+
+// exemplar links an observed value to the trace it belongs to.
+type exemplar struct {
+	traceid string
+	value   float64
+	time    time.Time
+}
+
+func (this *exemplar) String() string {
+	return fmt.Sprintf(" # {trace_id=%q} %s %.3f", this.traceid, formatFloat(this.value), float64(this.time.UnixNano())/1e9)
+}
+
+type HistogramVec struct {
+	desc
+	buckets []float64
+	lock    sync.Mutex
+	values  map[string]*histogram
+}
+
+func NewHistogramVec(name, help string, buckets []float64, labels ...string) *HistogramVec {
+	if buckets == nil {
+		buckets = DefaultBuckets
+	}
+	h := &HistogramVec{desc: desc{name, help, labels}, buckets: buckets, values: map[string]*histogram{}}
+	defaultRegistry.register(h)
+	return h
+}
+
+func (this *HistogramVec) Observe(v float64, labels ...string) {
+	this.ObserveWithExemplar(v, "", labels...)
+}
+
+// ObserveWithExemplar observes a value and keeps it as exemplar for its
+// bucket linked to the given trace id (if not empty).
+func (this *HistogramVec) ObserveWithExemplar(v float64, traceid string, labels ...string) {
+	key := this.key(labels)
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	h := this.values[key]
+	if h == nil {
+		h = &histogram{counts: make([]uint64, len(this.buckets)), exemplars: make([]*exemplar, len(this.buckets)+1)}
+		this.values[key] = h
+	}
+	bucket := len(this.buckets)
+	for i, b := range this.buckets {
+		if v <= b {
+			h.counts[i]++
+			if i < bucket {
+				bucket = i
+			}
+		}
+	}
+	h.count++
+	h.sum += v
+	if traceid != "" {
+		h.exemplars[bucket] = &exemplar{traceid: traceid, value: v, time: time.Now()}
+	}
+}
+
+func (this *HistogramVec) ObserveDuration(start time.Time, labels ...string) {
+	this.Observe(time.Now().Sub(start).Seconds(), labels...)
+}
+
+func (this *HistogramVec) write(w io.Writer, openmetrics bool) {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	this.header(w, "histogram", openmetrics)
+	keys := make([]string, 0, len(this.values))
+	for k := range this.values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		h := this.values[k]
+		ex := func(i int) string {
+			if openmetrics && h.exemplars[i] != nil {
+				return h.exemplars[i].String()
+			}
+			return ""
+		}
+		for i, b := range this.buckets {
+			fmt.Fprintf(w, "%s_bucket%s %d%s\n", this.name, this.labelString(k, "le", formatFloat(b)), h.counts[i], ex(i))
+		}
+		fmt.Fprintf(w, "%s_bucket%s %d%s\n", this.name, this.labelString(k, "le", "+Inf"), h.count, ex(len(this.buckets)))
+		fmt.Fprintf(w, "%s_sum%s %s\n", this.name, this.labelString(k), formatFloat(h.sum))
+		fmt.Fprintf(w, "%s_count%s %d\n", this.name, this.labelString(k), h.count)
+	}
+}
+
+func formatFloat(v float64) string {
+	if math.IsInf(v, +1) {
+		return "+Inf"
+	}
+	return fmt.Sprintf("%g", v)
+}
@@ -0,0 +1,216 @@
+/*
+ * Copyright 2019 SAP SE or an SAP affiliate company. All rights reserved. This file is licensed under the Apache Software License, v. 2 except as noted otherwise in the LICENSE file
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ *
+ */
+
+package provider
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/gardener/controller-manager-library/pkg/controllermanager/config"
+	"github.com/gardener/controller-manager-library/pkg/resources"
+	"github.com/gardener/controller-manager-library/pkg/server"
+	"k8s.io/apimachinery/pkg/api/errors"
+
+	api "github.com/gardener/external-dns-management/pkg/apis/dns/v1alpha1"
+	"github.com/gardener/external-dns-management/pkg/dns"
+)
+
+// OPT_ACME_TOKEN_FILE enables the api for ACME DNS-01 challenges.
+// Requests must provide the content of the file as bearer token.
+const OPT_ACME_TOKEN_FILE = "acme-token-file"
+
+// OPT_ACME_NAMESPACE is the namespace for the entries of the challenges.
+const OPT_ACME_NAMESPACE = "acme-namespace"
+
+const acmePath = "/dns/acme/"
+const acmeChallengePrefix = "_acme-challenge."
+const acmeTTL = 60
+
+func init() {
+	config.RegisterExtension(func(cfg *config.Config) {
+		opt, _ := cfg.AddStringOption(OPT_ACME_TOKEN_FILE)
+		opt.Description = "file with the bearer token required for the ACME DNS-01 challenge api (api disabled if not set)"
+		opt, _ = cfg.AddStringOption(OPT_ACME_NAMESPACE)
+		opt.Description = "namespace for the DNS entries of ACME DNS-01 challenges (default: default)"
+	})
+	server.Register(acmePath, acmeHandler)
+}
+
+// acmeStates keeps the states of the provisioning controllers used to
+// present challenges.
+var acmeStates = struct {
+	lock      sync.Mutex
+	tokenfile string
+	namespace string
+	states    map[string]*state
+}{states: map[string]*state{}}
+
+func registerACMEState(s *state) {
+	acmeStates.lock.Lock()
+	defer acmeStates.lock.Unlock()
+	if cfg := config.Get(s.controller.GetContext()); cfg != nil {
+		if o := cfg.GetOption(OPT_ACME_TOKEN_FILE); o != nil {
+			acmeStates.tokenfile = o.StringValue()
+		}
+		if o := cfg.GetOption(OPT_ACME_NAMESPACE); o != nil {
+			acmeStates.namespace = o.StringValue()
+		}
+	}
+	acmeStates.states[s.controller.GetName()] = s
+}
+
+// ChallengeRequest presents or cleans up the key of a DNS-01 challenge
+// for a fully qualified challenge name (_acme-challenge.<domain>).
+type ChallengeRequest struct {
+	FQDN string `json:"fqdn"`
+	Key  string `json:"key"`
+}
+
+type ChallengeResponse struct {
+	Entry string `json:"entry"`
+}
+
+func acmeHandler(w http.ResponseWriter, r *http.Request) {
+	acmeStates.lock.Lock()
+	tokenfile := acmeStates.tokenfile
+	namespace := acmeStates.namespace
+	states := []*state{}
+	for _, s := range acmeStates.states {
+		states = append(states, s)
+	}
+	acmeStates.lock.Unlock()
+
+	if tokenfile == "" {
+		http.NotFound(w, r)
+		return
+	}
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if !authorized(r, tokenfile) {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	var present bool
+	switch strings.Trim(strings.TrimPrefix(r.URL.Path, acmePath), "/") {
+	case "present":
+		present = true
+	case "cleanup":
+		present = false
+	default:
+		http.NotFound(w, r)
+		return
+	}
+
+	req := &ChallengeRequest{}
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+		http.Error(w, fmt.Sprintf("invalid request: %s", err), http.StatusBadRequest)
+		return
+	}
+	dnsname := strings.ToLower(dns.NormalizeHostname(req.FQDN))
+	if !strings.HasPrefix(dnsname, acmeChallengePrefix) || req.Key == "" {
+		http.Error(w, fmt.Sprintf("fqdn must start with %q and key is required", acmeChallengePrefix), http.StatusBadRequest)
+		return
+	}
+	if namespace == "" {
+		namespace = "default"
+	}
+
+	for _, s := range states {
+		if s.LookupProvider(dnsname) == nil {
+			continue
+		}
+		name, err := s.acmeChallenge(namespace, dnsname, req.Key, present)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(&ChallengeResponse{Entry: name.String()})
+		return
+	}
+	http.Error(w, fmt.Sprintf("no provider found for %q", dnsname), http.StatusNotFound)
+}
+
+func acmeEntryName(dnsname string) string {
+	return fmt.Sprintf("acme-%x", sha256.Sum256([]byte(dnsname)))[:21]
+}
+
+// acmeChallenge adds or removes the key of a challenge to/from the text
+// records of the entry for the challenge name. The entry is created for
+// the first key and deleted with the last one.
+func (this *state) acmeChallenge(namespace, dnsname, key string, present bool) (resources.ObjectName, error) {
+	name := resources.NewObjectName(namespace, acmeEntryName(dnsname))
+	res, err := this.controller.GetMainCluster().Resources().GetByExample(&api.DNSEntry{})
+	if err != nil {
+		return name, err
+	}
+	obj, err := res.GetInto(name, &api.DNSEntry{})
+	if err != nil {
+		if !errors.IsNotFound(err) {
+			return name, err
+		}
+		if !present {
+			return name, nil
+		}
+		ttl := int64(acmeTTL)
+		entry := &api.DNSEntry{}
+		entry.Namespace = name.Namespace()
+		entry.Name = name.Name()
+		entry.Spec.DNSName = dnsname
+		entry.Spec.TTL = &ttl
+		entry.Spec.Text = []string{key}
+		_, err = res.Create(entry)
+		return name, err
+	}
+
+	remaining := 0
+	f := func(data resources.ObjectData) (bool, error) {
+		e := data.(*api.DNSEntry)
+		texts := []string{}
+		for _, t := range e.Spec.Text {
+			if t != key {
+				texts = append(texts, t)
+			}
+		}
+		remaining = len(texts)
+		if present {
+			texts = append(texts, key)
+		} else if remaining == 0 {
+			// entry is deleted instead
+			return false, nil
+		}
+		mod := len(texts) != len(e.Spec.Text)
+		e.Spec.Text = texts
+		return mod, nil
+	}
+	if _, err = obj.Modify(f); err != nil {
+		return name, err
+	}
+	if !present && remaining == 0 {
+		err = res.Delete(obj.Data())
+		if errors.IsNotFound(err) {
+			err = nil
+		}
+	}
+	return name, err
+}
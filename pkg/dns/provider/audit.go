@@ -0,0 +1,273 @@
+/*
+ * Copyright 2019 SAP SE or an SAP affiliate company. All rights reserved. This file is licensed under the Apache Software License, v. 2 except as noted otherwise in the LICENSE file
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ *
+ */
+
+package provider
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gardener/controller-manager-library/pkg/logger"
+
+	"github.com/gardener/external-dns-management/pkg/dns"
+)
+
+// AuditRecord describes a change applied to a hosted zone.
+// Every record contains the hash of its predecessor in the
+// audit stream, so that removed or modified records can be detected.
+type AuditRecord struct {
+	Time     time.Time `json:"time"`
+	Provider string    `json:"provider"`
+	Zone     string    `json:"zone"`
+	Action   string    `json:"action"`
+	DNSName  string    `json:"dnsName"`
+	Type     string    `json:"type"`
+	Old      []string  `json:"old,omitempty"`
+	New      []string  `json:"new,omitempty"`
+	Trigger  string    `json:"trigger,omitempty"`
+	Error    string    `json:"error,omitempty"`
+	Previous string    `json:"previous"`
+	Hash     string    `json:"hash"`
+}
+
+// AuditSink receives the records for all changes applied by a controller.
+type AuditSink interface {
+	Record(logger logger.LogContext, records []*AuditRecord) error
+}
+
+// NewAuditSink creates the audit sink for a sink specification:
+//
+//	log           structured log stream of the controller
+//	file:<path>   file with one json record per line
+//	http(s)://... webhook receiving a json list of records by POST
+func NewAuditSink(spec string) (AuditSink, error) {
+	var sink AuditSink
+	last := ""
+	switch {
+	case spec == "":
+		return nil, nil
+	case spec == "log":
+		sink = &logAuditSink{}
+	case strings.HasPrefix(spec, "file:"):
+		f, err := os.OpenFile(spec[5:], os.O_CREATE|os.O_APPEND|os.O_RDWR, 0600)
+		if err != nil {
+			return nil, fmt.Errorf("cannot open audit file: %s", err)
+		}
+		last, err = lastAuditHash(f)
+		if err != nil {
+			f.Close()
+			return nil, fmt.Errorf("cannot read audit file: %s", err)
+		}
+		sink = &fileAuditSink{file: f}
+	case strings.HasPrefix(spec, "http://") || strings.HasPrefix(spec, "https://"):
+		sink = &webhookAuditSink{url: spec, client: &http.Client{Timeout: 10 * time.Second}}
+	default:
+		return nil, fmt.Errorf("invalid audit sink %q", spec)
+	}
+	return &chainedAuditSink{sink: sink, last: last}, nil
+}
+
+// lastAuditHash determines the hash of the last record of an audit
+// file to continue its chain after a restart.
+func lastAuditHash(f *os.File) (string, error) {
+	last := ""
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		record := &AuditRecord{}
+		if err := json.Unmarshal(line, record); err != nil {
+			return "", err
+		}
+		last = record.Hash
+	}
+	return last, scanner.Err()
+}
+
+// maxPendingAuditRecords limits the number of records kept for a retry
+// if the sink cannot be written.
+const maxPendingAuditRecords = 1000
+
+// chainedAuditSink links the records of a sink by their hashes.
+// The chain is only advanced by records successfully passed to the sink.
+// Records failed to be written are kept and passed again together with
+// the next records, so that the chain never refers to a lost record.
+type chainedAuditSink struct {
+	lock    sync.Mutex
+	last    string
+	pending []*AuditRecord
+	sink    AuditSink
+}
+
+func (this *chainedAuditSink) Record(logger logger.LogContext, records []*AuditRecord) error {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	last := this.last
+	if len(this.pending) > 0 {
+		last = this.pending[len(this.pending)-1].Hash
+	}
+	for _, r := range records {
+		r.Previous = last
+		r.Hash = ""
+		data, err := json.Marshal(r)
+		if err != nil {
+			return err
+		}
+		r.Hash = fmt.Sprintf("%x", sha256.Sum256(data))
+		last = r.Hash
+	}
+	batch := append(this.pending, records...)
+	if err := this.sink.Record(logger, batch); err != nil {
+		if len(batch) > maxPendingAuditRecords {
+			this.pending = nil
+			return fmt.Errorf("%s (%d records dropped)", err, len(batch))
+		}
+		this.pending = batch
+		return fmt.Errorf("%s (%d records kept for retry)", err, len(batch))
+	}
+	this.pending = nil
+	this.last = last
+	return nil
+}
+
+type logAuditSink struct{}
+
+func (this *logAuditSink) Record(logger logger.LogContext, records []*AuditRecord) error {
+	for _, r := range records {
+		data, err := json.Marshal(r)
+		if err != nil {
+			return err
+		}
+		logger.Infof("audit: %s", data)
+	}
+	return nil
+}
+
+type fileAuditSink struct {
+	file *os.File
+}
+
+func (this *fileAuditSink) Record(logger logger.LogContext, records []*AuditRecord) error {
+	buf := &bytes.Buffer{}
+	enc := json.NewEncoder(buf)
+	for _, r := range records {
+		if err := enc.Encode(r); err != nil {
+			return err
+		}
+	}
+	_, err := this.file.Write(buf.Bytes())
+	return err
+}
+
+type webhookAuditSink struct {
+	url    string
+	client *http.Client
+}
+
+func (this *webhookAuditSink) Record(logger logger.LogContext, records []*AuditRecord) error {
+	data, err := json.Marshal(records)
+	if err != nil {
+		return err
+	}
+	resp, err := this.client.Post(this.url, "application/json", bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("audit webhook returned %s", resp.Status)
+	}
+	return nil
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// audit reports the executed requests of a change group to the
+// configured audit sink. Every record gets the result of its own request,
+// the error of the whole execution is used only for requests without result.
+func (this *ChangeGroup) audit(logger logger.LogContext, model *ChangeModel, reqs []*ChangeRequest, err error) {
+	sink := model.config.AuditSink
+	if sink == nil {
+		return
+	}
+	now := time.Now().UTC()
+	records := []*AuditRecord{}
+	for _, r := range reqs {
+		record := &AuditRecord{
+			Time:     now,
+			Provider: this.name,
+			Zone:     model.zoneid,
+			Action:   r.Action,
+			Type:     r.Type,
+			Old:      recordValues(r.Deletion, r.Type),
+			New:      recordValues(r.Addition, r.Type),
+		}
+		if r.Addition != nil {
+			record.DNSName = r.Addition.Name
+		} else if r.Deletion != nil {
+			record.DNSName = r.Deletion.Name
+		}
+		finished := false
+		for done := r.Done; done != nil; {
+			switch h := done.(type) {
+			case *StatusUpdate:
+				record.Trigger = h.ObjectName().String()
+				done = nil
+			case *executionResult:
+				done = h.DoneHandler
+			case *trackingDoneHandler:
+				if h.finished {
+					finished = true
+					if h.err != nil {
+						record.Error = h.err.Error()
+					}
+				}
+				done = h.done
+			default:
+				done = nil
+			}
+		}
+		if !finished && err != nil {
+			record.Error = err.Error()
+		}
+		records = append(records, record)
+	}
+	if err := sink.Record(logger, records); err != nil {
+		logger.Errorf("cannot write %d audit records: %s", len(records), err)
+	}
+}
+
+func recordValues(set *dns.DNSSet, rtype string) []string {
+	if set == nil || set.Sets[rtype] == nil {
+		return nil
+	}
+	values := []string{}
+	for _, r := range set.Sets[rtype].Records {
+		values = append(values, r.Value)
+	}
+	return values
+}
@@ -0,0 +1,139 @@
+/*
+ * Copyright 2019 SAP SE or an SAP affiliate company. All rights reserved. This file is licensed under the Apache Software License, v. 2 except as noted otherwise in the LICENSE file
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ *
+ */
+
+package provider
+
+import (
+	"math/rand"
+	"time"
+)
+
+// Backoff describes the delays between retries of failed zone
+// reconcilations. The delay starts with Base and is doubled with
+// every further failure up to Max. It is varied randomly by the
+// fraction Jitter to spread the retries of different zones.
+type Backoff struct {
+	Base   time.Duration
+	Max    time.Duration
+	Jitter float64
+}
+
+// Delay returns the delay before the next retry after the given
+// number of subsequent failures.
+func (this Backoff) Delay(failures int) time.Duration {
+	d := this.Base
+	for i := 1; i < failures && d < this.Max; i++ {
+		d *= 2
+	}
+	if d > this.Max {
+		d = this.Max
+	}
+	if this.Jitter > 0 {
+		d += time.Duration(float64(d) * this.Jitter * (2*rand.Float64() - 1))
+	}
+	return d
+}
+
+// failed remembers a failed reconcilation of the zone and returns
+// the delay for the next retry.
+func (this *dnsHostedZone) failed(backoff Backoff) time.Duration {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	this.failures++
+	d := backoff.Delay(this.failures)
+	this.retryAt = time.Now().Add(d)
+	return d
+}
+
+// succeeded resets the backoff of the zone and remembers the time
+// of the successful reconcilation.
+func (this *dnsHostedZone) succeeded() {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	this.failures = 0
+	this.retryAt = time.Time{}
+	this.lastSync = time.Now()
+}
+
+// LastSync returns the time of the last successful reconcilation.
+func (this *dnsHostedZone) LastSync() time.Time {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	return this.lastSync
+}
+
+// backoffRemaining returns the time until the zone may be retried.
+func (this *dnsHostedZone) backoffRemaining() time.Duration {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	return time.Until(this.retryAt)
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// failureTracker observes the results of change requests passed
+// to a provider.
+type failureTracker struct {
+	failed    bool
+	throttled bool
+}
+
+func (this *failureTracker) wrap(reqs []*ChangeRequest) []*ChangeRequest {
+	result := make([]*ChangeRequest, len(reqs))
+	for i, r := range reqs {
+		c := *r
+		c.Done = &trackingDoneHandler{tracker: this, done: r.Done}
+		result[i] = &c
+	}
+	return result
+}
+
+// trackingDoneHandler additionally keeps the result of its
+// request for the audit log.
+type trackingDoneHandler struct {
+	tracker  *failureTracker
+	done     DoneHandler
+	finished bool
+	err      error
+}
+
+func (this *trackingDoneHandler) SetInvalid(err error) {
+	this.finished = true
+	this.err = err
+	if this.done != nil {
+		this.done.SetInvalid(err)
+	}
+}
+
+func (this *trackingDoneHandler) Failed(err error) {
+	this.finished = true
+	this.err = err
+	this.tracker.failed = true
+	if IsThrottlingError(err) {
+		this.tracker.throttled = true
+	}
+	if this.done != nil {
+		this.done.Failed(err)
+	}
+}
+
+func (this *trackingDoneHandler) Succeeded() {
+	this.finished = true
+	this.err = nil
+	if this.done != nil {
+		this.done.Succeeded()
+	}
+}
@@ -0,0 +1,167 @@
+/*
+ * Copyright 2019 SAP SE or an SAP affiliate company. All rights reserved. This file is licensed under the Apache Software License, v. 2 except as noted otherwise in the LICENSE file
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ *
+ */
+
+package provider
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/gardener/controller-manager-library/pkg/logger"
+	"github.com/gardener/controller-manager-library/pkg/resources"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+
+	api "github.com/gardener/external-dns-management/pkg/apis/dns/v1alpha1"
+	"github.com/gardener/external-dns-management/pkg/dns"
+)
+
+// BACKUP_LABEL marks the config maps containing zone backups.
+const BACKUP_LABEL = "dns.gardener.cloud/backup"
+
+// BACKUP_KEY is the key of the zone backup in the config map.
+const BACKUP_KEY = "zone.json"
+
+// ZoneBackup is the stored backup of the records of a hosted zone
+// managed by a controller.
+type ZoneBackup struct {
+	Controller string                  `json:"controller"`
+	Owner      string                  `json:"owner"`
+	Provider   string                  `json:"provider"`
+	Type       string                  `json:"type"`
+	Zone       string                  `json:"zone"`
+	Domain     string                  `json:"domain"`
+	Time       time.Time               `json:"time"`
+	Records    map[string]*RecordsInfo `json:"records"`
+}
+
+// BACKUP_OWNER_ANNOTATION is the annotation of a backup config map
+// containing the identifier of the controller writing the backup.
+const BACKUP_OWNER_ANNOTATION = "dns.gardener.cloud/owner"
+
+// BackupName returns the name of the config map for the backup of a zone
+// written by the controller with the given identifier. Every controller
+// uses its own config map per zone, so that backups of controllers sharing
+// a zone never overwrite each other.
+func BackupName(owner, zoneid string) string {
+	return fmt.Sprintf("dns-backup-%x", sha256.Sum256([]byte(owner+"/"+zoneid)))[:27]
+}
+
+// backupDue checks whether a new backup of the zone is required and
+// remembers the backup time.
+func (this *dnsHostedZone) backupDue(interval time.Duration) bool {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	if time.Since(this.lastBackup) < interval {
+		return false
+	}
+	this.lastBackup = time.Now()
+	return true
+}
+
+// backupZone stores the records owned by this controller in a config map,
+// if the backup of the zone is due.
+func (this *state) backupZone(logger logger.LogContext, zone *dnsHostedZone, providers DNSProviders) {
+	if this.config.BackupInterval <= 0 || !zone.backupDue(this.config.BackupInterval) {
+		return
+	}
+	var p DNSProvider
+	for _, p = range providers {
+		break
+	}
+	if p == nil {
+		return
+	}
+	sets, err := p.GetDNSSets(zone.Id(), nil)
+	if err != nil {
+		logger.Warnf("cannot read zone %q for backup: %s", zone.Id(), err)
+		zone.resetBackup()
+		return
+	}
+	owned := dns.DNSSets{}
+	for name, set := range sets {
+		if set.IsOwnedBy(this.owners) {
+			owned[name] = set
+		}
+	}
+	records := recordsInfo(owned)
+	for _, info := range records {
+		delete(info.Sets, dns.RS_META)
+	}
+	backup := &ZoneBackup{
+		Controller: this.controller.GetName(),
+		Owner:      this.config.Ident,
+		Provider:   p.ObjectName().String(),
+		Type:       p.Object().Data().(*api.DNSProvider).Spec.Type,
+		Zone:       zone.Id(),
+		Domain:     zone.Domain(),
+		Time:       time.Now().UTC(),
+		Records:    records,
+	}
+	if err := this.writeBackup(backup); err != nil {
+		logger.Warnf("cannot write backup for zone %q: %s", zone.Id(), err)
+		zone.resetBackup()
+		return
+	}
+	logger.Infof("backup of %d dns names of zone %q written", len(records), zone.Id())
+}
+
+func (this *dnsHostedZone) resetBackup() {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	this.lastBackup = time.Time{}
+}
+
+func (this *state) writeBackup(backup *ZoneBackup) error {
+	data, err := json.MarshalIndent(backup, "", "  ")
+	if err != nil {
+		return err
+	}
+	res, err := this.controller.GetMainCluster().Resources().GetByExample(&corev1.ConfigMap{})
+	if err != nil {
+		return err
+	}
+	name := resources.NewObjectName(this.config.BackupNamespace, BackupName(backup.Owner, backup.Zone))
+	obj, err := res.GetInto(name, &corev1.ConfigMap{})
+	if err != nil {
+		if !errors.IsNotFound(err) {
+			return err
+		}
+		cm := &corev1.ConfigMap{}
+		cm.Namespace = name.Namespace()
+		cm.Name = name.Name()
+		cm.Labels = map[string]string{BACKUP_LABEL: "true"}
+		cm.Annotations = map[string]string{BACKUP_LABEL: backup.Zone, BACKUP_OWNER_ANNOTATION: backup.Owner}
+		cm.Data = map[string]string{BACKUP_KEY: string(data)}
+		_, err = res.Create(cm)
+		return err
+	}
+	f := func(o resources.ObjectData) (bool, error) {
+		cm := o.(*corev1.ConfigMap)
+		if owner := cm.Annotations[BACKUP_OWNER_ANNOTATION]; owner != "" && owner != backup.Owner {
+			return false, fmt.Errorf("config map %s belongs to %q", name, owner)
+		}
+		if cm.Data == nil {
+			cm.Data = map[string]string{}
+		}
+		cm.Data[BACKUP_KEY] = string(data)
+		return true, nil
+	}
+	_, err = obj.Modify(f)
+	return err
+}
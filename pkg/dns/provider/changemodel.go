@@ -19,12 +19,19 @@ package provider
 import (
 	"fmt"
 	"net"
+	"reflect"
 	"sort"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/gardener/external-dns-management/pkg/dns"
+	"github.com/gardener/external-dns-management/pkg/dns/tracing"
 	"github.com/gardener/controller-manager-library/pkg/logger"
+	"github.com/gardener/controller-manager-library/pkg/resources"
 	"github.com/gardener/controller-manager-library/pkg/utils"
+
+	corev1 "k8s.io/api/core/v1"
 )
 
 ////////////////////////////////////////////////////////////////////////////////
@@ -51,6 +58,17 @@ func NewChangeRequest(action string, rtype string, del, add *dns.DNSSet, done Do
 	return &ChangeRequest{Action: action, Type: rtype, Addition: add, Deletion: del, Done: done}
 }
 
+func (this *ChangeRequest) String() string {
+	set := this.Addition
+	if set == nil {
+		set = this.Deletion
+	}
+	if set == nil {
+		return fmt.Sprintf("%s %s record set", this.Action, this.Type)
+	}
+	return fmt.Sprintf("%s %s record set %s:%s", this.Action, this.Type, set.Name, set.Sets[this.Type].RecordString())
+}
+
 type ChangeGroup struct {
 	name     string
 	provider DNSProvider
@@ -67,8 +85,25 @@ func (this *ChangeGroup) cleanup(logger logger.LogContext, model *ChangeModel) b
 	for _, s := range this.dnssets {
 		_, ok := model.applied[s.Name]
 		if !ok {
+			if s.IsOwnedBy(model.owners) && (model.orphanAll || model.orphans.Contains(s.Name)) {
+				// keep the records, but remove the ownership
+				model.Infof("orphaning managed set '%s'", s.Name)
+				this.provider.Object().Eventf(corev1.EventTypeNormal, "orphan", "keeping record set %s in zone %s without owner", s.Name, model.zoneid)
+				if s.Sets[dns.RS_META] != nil {
+					mod = true
+					this.addDeleteRequest(s, dns.RS_META, nil)
+				}
+				continue
+			}
 			if s.IsOwnedBy(model.owners) {
+				since := model.staleSince(s.Name)
+				if !model.deleteStale(since) {
+					model.Infof("keeping stale managed set '%s' (since %s)", s.Name, since.Format(time.RFC3339))
+					model.addStale(this.provider, s.Name, since)
+					continue
+				}
 				model.Infof("found unapplied managed set '%s'", s.Name)
+				this.provider.Object().Eventf(corev1.EventTypeNormal, "cleanup", "deleting record set %s in zone %s not requested by any entry", s.Name, model.zoneid)
 				for ty := range s.Sets {
 					mod = true
 					this.addDeleteRequest(s, ty, nil)
@@ -85,15 +120,54 @@ func (this *ChangeGroup) update(logger logger.LogContext, model *ChangeModel) bo
 
 	reqs := this.requests
 	if reqs != nil && len(reqs) > 0 {
-		err := this.provider.ExecuteRequests(logger, model.zoneid, reqs)
+		if this.provider.IsDryRun() {
+			this.plan(logger, model)
+			return ok
+		}
+		span := tracing.StartSpan("provider.execute", model.span, "provider", this.name, "zone", model.zoneid, "requests", strconv.Itoa(len(reqs)))
+		tracker := &failureTracker{}
+		tracked := tracker.wrap(reqs)
+		err := this.provider.ExecuteRequests(logger, model.zoneid, tracked)
+		model.executed.Add(this.provider.ObjectName())
+		span.End(err)
+		this.audit(logger, model, tracked, err)
 		if err != nil {
 			model.Errorf("entry reconcilation failed for %s: %s", this.name, err)
 			ok = false
 		}
+		if tracker.failed {
+			model.Errorf("change requests failed for %s", this.name)
+			ok = false
+		}
+		if tracker.throttled || IsThrottlingError(err) {
+			model.throttled = true
+		}
 	}
 	return ok
 }
 
+// plan just reports the requests of a provider in dry run mode
+// instead of executing them. Events are only emitted for requests
+// not planned by the former reconcilation of the zone, the entries
+// get a status indicating the dry run.
+func (this *ChangeGroup) plan(logger logger.LogContext, model *ChangeModel) {
+	model.Infof("dry run: %d requests for %s are not executed", len(this.requests), this.name)
+	plan := utils.StringSet{}
+	for _, r := range this.requests {
+		plan.Add(r.String())
+	}
+	added := this.provider.ReportPlan(model.zoneid, plan)
+	for _, r := range this.requests {
+		model.Infof("dry run: planned change for zone %s: %s", model.zoneid, r)
+		if added.Contains(r.String()) {
+			this.provider.Object().Eventf(corev1.EventTypeNormal, "dryrun", "planned change for zone %s: %s", model.zoneid, r)
+		}
+		if s, ok := r.Done.(*StatusUpdate); ok {
+			s.Planned()
+		}
+	}
+}
+
 func (this *ChangeGroup) addCreateRequest(dnsset *dns.DNSSet, rtype string, done DoneHandler) {
 	this.addChangeRequest(R_CREATE, nil, dnsset, rtype, done)
 }
@@ -104,6 +178,19 @@ func (this *ChangeGroup) addDeleteRequest(dnsset *dns.DNSSet, rtype string, done
 	this.addChangeRequest(R_DELETE, dnsset, nil, rtype, done)
 }
 func (this *ChangeGroup) addChangeRequest(action string, old, new *dns.DNSSet, rtype string, done DoneHandler) {
+	if s, ok := done.(*StatusUpdate); ok {
+		s.addAction(action, rtype)
+		if rtype != dns.RS_META {
+			var oldrs, newrs *dns.RecordSet
+			if old != nil {
+				oldrs = old.Sets[rtype]
+			}
+			if new != nil {
+				newrs = new.Sets[rtype]
+			}
+			s.addDiff(rtype, oldrs.Diff(newrs))
+		}
+	}
 	r := NewChangeRequest(action, rtype, old, new, done)
 	this.requests = append(this.requests, r)
 }
@@ -121,6 +208,25 @@ type ChangeModel struct {
 	applied        map[string]*dns.DNSSet
 	dangling       *ChangeGroup
 	providergroups map[DNSProvider]*ChangeGroup
+	span           *tracing.Span
+	filter         *ZoneFilter
+	refs           map[string]resources.ObjectName
+	orphans        utils.StringSet
+	protected      utils.StringSet
+	throttled      bool
+	// orphanAll orphans all managed record sets not requested by an entry.
+	orphanAll bool
+	// stale are the times stale record sets have been found first
+	// by former reconcilations of the zone.
+	stale map[string]time.Time
+	// found are the stale record sets found by this reconcilation, for
+	// the provider status they are additionally kept by provider as long
+	// as they are not deleted.
+	found       map[string]time.Time
+	staleByProv map[resources.ObjectName]map[string]time.Time
+	// executed are the providers which executed requests for the zone.
+	// Only their zone caches reflect the executed requests.
+	executed resources.ObjectNameSet
 }
 
 func NewChangeModel(logger logger.LogContext, owners utils.StringSet, config Config, zoneid string, providers DNSProviders) *ChangeModel {
@@ -131,7 +237,14 @@ func NewChangeModel(logger logger.LogContext, owners utils.StringSet, config Con
 		zoneid:         zoneid,
 		providers:      providers,
 		applied:        map[string]*dns.DNSSet{},
+		refs:           map[string]resources.ObjectName{},
+		orphans:        utils.StringSet{},
+		protected:      utils.StringSet{},
 		providergroups: map[DNSProvider]*ChangeGroup{},
+		stale:          map[string]time.Time{},
+		found:          map[string]time.Time{},
+		staleByProv:    map[resources.ObjectName]map[string]time.Time{},
+		executed:       resources.ObjectNameSet{},
 	}
 }
 
@@ -152,6 +265,15 @@ func (this *ChangeModel) getDefaultProvider() DNSProvider {
 	return provider
 }
 
+// lookupProvider returns the provider for a dns name. Names of entries
+// explicitly referencing a provider are always handled by this provider.
+func (this *ChangeModel) lookupProvider(name string) DNSProvider {
+	if ref := this.refs[name]; ref != nil {
+		return this.providers[ref]
+	}
+	return this.providers.LookupFor(name)
+}
+
 func (this *ChangeModel) dumpf(fmt string, args ...interface{}) {
 	this.Debugf(fmt, args...)
 }
@@ -161,14 +283,16 @@ func (this *ChangeModel) Setup() error {
 	if provider == nil {
 		return fmt.Errorf("no provider found for zone %q", this.zoneid)
 	}
-	sets, err := provider.GetDNSSets(this.zoneid)
+	span := tracing.StartSpan("provider.getdnssets", this.span, "provider", provider.ObjectName().String(), "zone", this.zoneid)
+	sets, err := provider.GetDNSSets(this.zoneid, this.filter)
+	span.End(err)
 	if err != nil {
 		return err
 	}
 	this.dangling = newChangeGroup("dangling entries", provider)
 	for dnsName, set := range sets {
 		var view *ChangeGroup
-		provider = this.providers.LookupFor(dnsName)
+		provider = this.lookupProvider(dnsName)
 		if provider != nil {
 			this.dumpf("  %s: %d types (provider %s)", dnsName, len(set.Sets), provider.ObjectName())
 			view = this.getProviderView(provider)
@@ -186,12 +310,18 @@ func (this *ChangeModel) Setup() error {
 }
 
 func (this *ChangeModel) Check(name string, done DoneHandler, targets ...Target) (bool, error) {
-	return this.Exec(false, name, done, targets...)
+	return this.Exec(false, name, done, targets)
 }
 func (this *ChangeModel) Apply(name string, done DoneHandler, targets ...Target) (bool, error) {
-	return this.Exec(true, name, done, targets...)
+	return this.Exec(true, name, done, targets)
 }
-func (this *ChangeModel) Exec(apply bool, name string, done DoneHandler, targets ...Target) (bool, error) {
+func (this *ChangeModel) Exec(apply bool, name string, done DoneHandler, targets Targets) (bool, error) {
+	p := this.lookupProvider(name)
+	protected := this.protected.Contains(name) || (p != nil && p.IsProtected())
+	if apply && protected {
+		// never cleanup protected records, even without targets
+		this.applied[name] = nil
+	}
 	if len(targets) == 0 {
 		return false, nil
 	}
@@ -199,7 +329,6 @@ func (this *ChangeModel) Exec(apply bool, name string, done DoneHandler, targets
 	if apply {
 		this.applied[name] = nil
 	}
-	p := this.providers.LookupFor(name)
 	if p == nil {
 		err := fmt.Errorf("no provider found for %q", name)
 		if done != nil {
@@ -210,7 +339,11 @@ func (this *ChangeModel) Exec(apply bool, name string, done DoneHandler, targets
 
 	view := this.getProviderView(p)
 	oldset := view.dnssets[name]
-	newset := this.NewDNSSetForTargets(name, oldset, this.config.TTL, targets...)
+	ttl, adjusted := p.TTLLimits().Effective(targets.TTL())
+	if s, ok := done.(*StatusUpdate); ok {
+		s.setTTL(ttl, adjusted)
+	}
+	newset := this.NewDNSSetForTargets(name, oldset, ttl, targets...)
 	mod := false
 	if oldset != nil {
 		if this.IsForeign(oldset) {
@@ -220,9 +353,23 @@ func (this *ChangeModel) Exec(apply bool, name string, done DoneHandler, targets
 			}
 			return false, err
 		} else {
+			if protected {
+				if err := checkProtected(oldset, newset); err != nil {
+					if done != nil {
+						done.SetInvalid(err)
+					}
+					return false, err
+				}
+			}
 			if !this.Owns(oldset) {
-				this.Infof("catch entry %q by reassigning owner", name)
+				if oldset.GetOwner() != "" {
+					this.Infof("adopt entry %q from owner %q", name, oldset.GetOwner())
+				} else {
+					this.Infof("catch entry %q by reassigning owner", name)
+				}
 			}
+			// changed metadata requires an update of all records
+			metamod := !reflect.DeepEqual(oldset.GetMetadata(), newset.GetMetadata())
 			for ty, rset := range newset.Sets {
 				curset := oldset.Sets[ty]
 				if curset == nil {
@@ -231,10 +378,10 @@ func (this *ChangeModel) Exec(apply bool, name string, done DoneHandler, targets
 					}
 					mod = true
 				} else {
-					olddns, _ := dns.MapToProvider(ty, oldset)
-					newdns, _ := dns.MapToProvider(ty, newset)
+					olddns, oldrs := dns.MapToProvider(ty, oldset)
+					newdns, newrs := dns.MapToProvider(ty, newset)
 					if olddns == newdns {
-						if !curset.Match(rset) {
+						if !oldrs.Match(newrs) || (ty != dns.RS_META && (curset.TTL != rset.TTL || metamod)) {
 							if apply {
 								view.addUpdateRequest(oldset, newset, ty, done)
 							}
@@ -283,12 +430,37 @@ func (this *ChangeModel) Exec(apply bool, name string, done DoneHandler, targets
 	return mod, nil
 }
 
+// checkProtected fails if the records of a protected dns name would be
+// deleted or modified. Only new record types and changes of the
+// time-to-live are accepted.
+func checkProtected(oldset, newset *dns.DNSSet) error {
+	for ty := range oldset.Sets {
+		if ty == dns.RS_META {
+			continue
+		}
+		if newset.Sets[ty] == nil {
+			return fmt.Errorf("dns name %q is protected: %s records would be deleted", oldset.Name, ty)
+		}
+		olddns, oldrs := dns.MapToProvider(ty, oldset)
+		newdns, newrs := dns.MapToProvider(ty, newset)
+		if olddns != newdns || !oldrs.Match(newrs) {
+			return fmt.Errorf("dns name %q is protected: %s records would be modified", oldset.Name, ty)
+		}
+	}
+	return nil
+}
+
 func (this *ChangeModel) Cleanup(logger logger.LogContext) bool {
 	mod := false
+	// all groups must be checked to find all stale record sets
 	for _, view := range this.providergroups {
-		mod = mod || view.cleanup(logger, this)
+		if view.cleanup(logger, this) {
+			mod = true
+		}
+	}
+	if this.dangling.cleanup(logger, this) {
+		mod = true
 	}
-	mod = mod || this.dangling.cleanup(logger, this)
 	if mod {
 		logger.Infof("found entries to be deleted")
 	}
@@ -297,16 +469,71 @@ func (this *ChangeModel) Cleanup(logger logger.LogContext) bool {
 
 func (this *ChangeModel) Update(logger logger.LogContext) error {
 	failed := false
+	// execute the requests of all providers, even if one of them fails
 	for _, view := range this.providergroups {
-		failed = failed || !view.update(logger, this)
+		if !view.update(logger, this) {
+			failed = true
+		}
+	}
+	if !this.dangling.update(logger, this) {
+		failed = true
 	}
-	failed = failed || !this.dangling.update(logger, this)
+	this.invalidateCaches()
 	if failed {
-		return fmt.Errorf("entry reconcilation failed for some provider(s)")
+		err := fmt.Errorf("entry reconcilation failed for some provider(s)")
+		if this.throttled {
+			return NewThrottlingError(err)
+		}
+		return err
 	}
 	return nil
 }
 
+// invalidateCaches discards the cached zone state of all providers
+// of the zone, which did not see all executed requests. The zone
+// state is read by any of the providers sharing the zone, so every
+// cache must reflect the changes done by the others.
+func (this *ChangeModel) invalidateCaches() {
+	if len(this.executed) == 0 {
+		return
+	}
+	for _, p := range this.providers {
+		if len(this.executed) == 1 && this.executed.Contains(p.ObjectName()) {
+			continue
+		}
+		p.InvalidateZoneCache(this.zoneid)
+	}
+}
+
+// staleSince returns the time a stale record set has been found first
+// and remembers it for the next reconcilation.
+func (this *ChangeModel) staleSince(name string) time.Time {
+	since, ok := this.stale[name]
+	if !ok {
+		since = time.Now()
+	}
+	this.found[name] = since
+	return since
+}
+
+// deleteStale decides whether a record set stale since the given time
+// is deleted.
+func (this *ChangeModel) deleteStale(since time.Time) bool {
+	if this.config.StaleRecords == STALE_RECORDS_REPORT {
+		return false
+	}
+	return time.Now().Sub(since) >= this.config.StaleRecordGracePeriod
+}
+
+func (this *ChangeModel) addStale(p DNSProvider, name string, since time.Time) {
+	m := this.staleByProv[p.ObjectName()]
+	if m == nil {
+		m = map[string]time.Time{}
+		this.staleByProv[p.ObjectName()] = m
+	}
+	m[name] = since
+}
+
 /////////////////////////////////////////////////////////////////////////////////
 // DNSSets
 
@@ -314,8 +541,11 @@ func (this *ChangeModel) Owns(set *dns.DNSSet) bool {
 	return set.IsOwnedBy(this.owners)
 }
 
+// IsForeign checks whether a set is owned by another controller. Sets of
+// adopted owners are not foreign, they are taken over by the entries
+// of this controller.
 func (this *ChangeModel) IsForeign(set *dns.DNSSet) bool {
-	return set.IsForeign(this.owners)
+	return set.IsForeign(this.owners) && !set.IsOwnedBy(this.config.AdoptOwners)
 }
 
 func (this *ChangeModel) NewDNSSetForTargets(name string, base *dns.DNSSet, ttl int64, targets ...Target) *dns.DNSSet {
@@ -329,7 +559,7 @@ func (this *ChangeModel) NewDNSSetForTargets(name string, base *dns.DNSSet, ttl
 
 	if base == nil || !this.IsForeign(base) {
 		set.SetOwner(this.config.Ident)
-		set.SetAttr(dns.ATTR_PREFIX, dns.TxtPrefix)
+		set.InitRegistry()
 	}
 
 	targetsets := set.Sets
@@ -341,12 +571,12 @@ func (this *ChangeModel) NewDNSSetForTargets(name string, base *dns.DNSSet, ttl
 			addrs, err := net.LookupHost(t.GetHostName())
 			if err == nil {
 				for _, addr := range addrs {
-					AddRecord(targetsets, dns.RS_A, addr, ttl)
+					AddRecord(targetsets, addressRecordType(net.ParseIP(addr)), addr, ttl)
 				}
 			} else {
 				this.Errorf("cannot lookup '%s': %s", t.GetHostName(), err)
 			}
-			this.Debugf("mapping target '%s' to A records: %s", t.GetHostName(), strings.Join(addrs, ","))
+			this.Debugf("mapping target '%s' to address records: %s", t.GetHostName(), strings.Join(addrs, ","))
 		} else {
 			AddRecord(targetsets, ty, t.GetHostName(), ttl)
 		}
@@ -356,6 +586,12 @@ func (this *ChangeModel) NewDNSSetForTargets(name string, base *dns.DNSSet, ttl
 		sort.Strings(cnames)
 		set.SetAttr(dns.ATTR_CNAMES, strings.Join(cnames, ","))
 	}
+	// the metadata cannot be kept in the registry format of external-dns
+	if len(targets) > 0 && targets[0].GetEntry() != nil && this.Owns(set) && set.GetAttr(dns.ATTR_HERITAGE) == "" {
+		for k, v := range targets[0].GetEntry().Metadata() {
+			set.SetMetadata(k, v)
+		}
+	}
 	return set
 }
 
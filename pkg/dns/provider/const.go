@@ -23,3 +23,66 @@ package provider
 const OPT_IDENTIFIER = "identifier"
 const OPT_DRYRUN = "dry-run"
 const OPT_TTL = "ttl"
+const OPT_MIN_TTL = "min-ttl"
+const OPT_MAX_TTL = "max-ttl"
+const OPT_CACHE_TTL = "cache-ttl"
+const OPT_BATCH_SIZE = "change-batch-size"
+const OPT_COALESCE_INTERVAL = "change-coalesce-interval"
+const OPT_PROVIDER_ZONE_WORKERS = "provider-zone-workers"
+const OPT_PROPAGATION_CHECK = "propagation-check"
+const OPT_PROPAGATION_RESOLVERS = "propagation-resolvers"
+const OPT_PROPAGATION_TIMEOUT = "propagation-timeout"
+const OPT_TXT_REGISTRY = "txt-registry"
+const OPT_EXTERNAL_DNS_PREFIX = "external-dns-txt-prefix"
+const OPT_ADOPT_OWNERS = "adopt-owners"
+const OPT_BACKUP_INTERVAL = "backup-interval"
+const OPT_BACKUP_NAMESPACE = "backup-namespace"
+const OPT_AUDIT_SINK = "audit-sink"
+const OPT_BACKOFF_BASE = "backoff-base"
+const OPT_BACKOFF_MAX = "backoff-max"
+const OPT_BACKOFF_JITTER = "backoff-jitter"
+const OPT_REPORT_INTERVAL = "report-interval"
+const OPT_STALE_RECORDS = "stale-records"
+const OPT_STALE_RECORD_GRACE_PERIOD = "stale-record-grace-period"
+const OPT_PROVIDER_HEALTH_INTERVAL = "provider-health-interval"
+const OPT_PROVIDER_READINESS = "provider-readiness"
+
+// Handling of record sets owned by the controller without entry
+const STALE_RECORDS_DELETE = "delete"
+const STALE_RECORDS_REPORT = "report"
+
+/*
+  Annotations for DNSProvider objects
+*/
+
+// DRYRUN_ANNOTATION switches a single provider into plan-only mode.
+const DRYRUN_ANNOTATION = "dns.gardener.cloud/dry-run"
+
+// BATCH_SIZE_ANNOTATION overrides the maximum number of changes per change
+// request for a single provider.
+const BATCH_SIZE_ANNOTATION = "dns.gardener.cloud/change-batch-size"
+
+// ZONE_WORKERS_ANNOTATION overrides the maximum number of concurrent zone
+// reconcilations for a single provider.
+const ZONE_WORKERS_ANNOTATION = "dns.gardener.cloud/zone-workers"
+
+// RESYNC_ANNOTATION forces a complete resync of the hosted zones of a
+// provider whenever its value (e.g. a timestamp) is changed.
+const RESYNC_ANNOTATION = "dns.gardener.cloud/resync"
+
+/*
+  Annotations for DNSEntry and DNSProvider objects
+*/
+
+// PROTECTED_ANNOTATION protects the records of an entry (or of all entries
+// of a provider) against deletion and destructive modification.
+const PROTECTED_ANNOTATION = "dns.gardener.cloud/protected"
+
+/*
+  Outcomes of reconcilations used for the metrics
+*/
+
+const OUTCOME_SUCCESS = "success"
+const OUTCOME_ERROR = "error"
+const OUTCOME_THROTTLED = "throttled"
+const OUTCOME_INVALID = "invalid"
@@ -17,10 +17,12 @@
 package provider
 
 import (
+	"fmt"
 	"github.com/gardener/external-dns-management/pkg/crds"
 	"github.com/gardener/external-dns-management/pkg/dns/source"
 	"time"
 
+	"github.com/gardener/controller-manager-library/pkg/controllermanager/config"
 	"github.com/gardener/controller-manager-library/pkg/controllermanager/controller"
 	"github.com/gardener/controller-manager-library/pkg/controllermanager/controller/reconcile"
 	"github.com/gardener/controller-manager-library/pkg/logger"
@@ -28,6 +30,9 @@ import (
 	"github.com/gardener/controller-manager-library/pkg/utils"
 
 	api "github.com/gardener/external-dns-management/pkg/apis/dns/v1alpha1"
+	"github.com/gardener/external-dns-management/pkg/dns"
+	"github.com/gardener/external-dns-management/pkg/dns/logging"
+	"github.com/gardener/external-dns-management/pkg/dns/sharding"
 	dnsutils "github.com/gardener/external-dns-management/pkg/dns/utils"
 
 	corev1 "k8s.io/api/core/v1"
@@ -47,9 +52,32 @@ func DNSController(name string, factory DNSHandlerFactory) controller.Configurat
 		DefaultedStringOption(OPT_IDENTIFIER, "dnscontroller", "Identifier used to mark DNS entries").
 		DefaultedBoolOption(OPT_DRYRUN, false, "just check, don't modify").
 		DefaultedIntOption(OPT_TTL, 300, "Default time-to-live for DNS entries").
+		DefaultedIntOption(OPT_MIN_TTL, 0, "Minimum time-to-live for DNS entries (0 for no limit)").
+		DefaultedIntOption(OPT_MAX_TTL, 0, "Maximum time-to-live for DNS entries (0 for no limit)").
+		DefaultedIntOption(OPT_CACHE_TTL, 120, "Time-to-live in seconds for cached zone states (0 disables the cache)").
+		DefaultedIntOption(OPT_BATCH_SIZE, 0, "Maximum number of changes per change request (0 uses the provider default)").
+		DefaultedIntOption(OPT_COALESCE_INTERVAL, 0, "Delay in seconds to collect entry changes for a zone before updating it").
+		DefaultedIntOption(OPT_PROVIDER_ZONE_WORKERS, 1, "Maximum number of concurrent zone reconcilations per provider (0 for unlimited)").
+		DefaultedBoolOption(OPT_PROPAGATION_CHECK, false, "Resolve the dns names of ready entries and report the result in the entry status").
+		DefaultedStringOption(OPT_PROPAGATION_RESOLVERS, "", "Comma separated list of name servers (host[:port], tls://host[:port] or https://url) used for the propagation check (default: system resolver)").
+		DefaultedIntOption(OPT_PROPAGATION_TIMEOUT, 0, "Time in seconds after which a pending propagation is reported as timed out (0 for no timeout)").
+		DefaultedStringOption(OPT_TXT_REGISTRY, dns.REGISTRY_DEFAULT, "Format used to store the owner of new DNS names (default or external-dns)").
+		DefaultedStringOption(OPT_EXTERNAL_DNS_PREFIX, "", "Prefix of the TXT records in the external-dns registry format (required for txt registry external-dns)").
+		DefaultedIntOption(OPT_BACKUP_INTERVAL, 0, "Interval in seconds for the backup of the managed records of all zones (0 disables the backup)").
+		DefaultedStringOption(OPT_BACKUP_NAMESPACE, "default", "Namespace for the config maps with the zone backups").
+		DefaultedIntOption(OPT_BACKOFF_BASE, 5, "Initial delay in seconds for retries of failed zone reconcilations").
+		DefaultedIntOption(OPT_BACKOFF_MAX, 600, "Maximum delay in seconds for retries of failed zone reconcilations").
+		DefaultedIntOption(OPT_BACKOFF_JITTER, 20, "Random variation of the retry delay in percent").
+		DefaultedIntOption(OPT_REPORT_INTERVAL, 60, "Interval in seconds for updating the DNSReport of the controller (0 disables the report)").
+		DefaultedStringOption(OPT_AUDIT_SINK, "", "Sink for the audit records of applied changes (log, file:<path> or webhook url)").
+		DefaultedStringOption(OPT_STALE_RECORDS, STALE_RECORDS_DELETE, "Handling of record sets owned by the controller without entry (delete or report)").
+		DefaultedIntOption(OPT_STALE_RECORD_GRACE_PERIOD, 0, "Time in seconds stale record sets are kept before they are deleted").
+		DefaultedIntOption(OPT_PROVIDER_HEALTH_INTERVAL, 300, "Interval in seconds for checking the credentials and hosted zones of all providers (0 disables the check)").
+		DefaultedBoolOption(OPT_PROVIDER_READINESS, false, "Report the controller as not ready if there is no healthy provider of its provider type").
+		DefaultedStringOption(OPT_ADOPT_OWNERS, "", "Comma separated list of identifiers of other controllers whose records are taken over for the own entries").
 		Reconciler(DNSReconcilerType(factory)).
 		Cluster(TARGET_CLUSTER).
-		CustomResourceDefinitions(crds.DNSEntryCRD).
+		CustomResourceDefinitions(crds.DNSEntryCRD, crds.DNSReportCRD).
 		MainResource(api.GroupName, api.DNSEntryKind).
 		DefaultWorkerPool(2, 0).
 		Cluster(PROVIDER_CLUSTER).
@@ -59,7 +87,8 @@ func DNSController(name string, factory DNSHandlerFactory) controller.Configurat
 			controller.NewResourceKey(api.GroupName, api.DNSProviderKind),
 			controller.NewResourceKey("core", "Secret"),
 		).
-		WorkerPool("dns", 1, 30*time.Second).CommandMatchers(utils.NewStringGlobMatcher("hostedzone:*"))
+		WorkerPool("dns", 2, 30*time.Second).CommandMatchers(utils.NewStringGlobMatcher("hostedzone:*")).
+		WorkerPool("report", 1, 0).Commands(CMD_REPORT, CMD_PROVIDER_HEALTH)
 }
 
 type reconciler struct {
@@ -84,7 +113,18 @@ func DNSReconcilerType(factory DNSHandlerFactory) controller.ReconcilerType {
 ///////////////////////////////////////////////////////////////////////////////
 
 func Create(c controller.Interface, factory DNSHandlerFactory) (reconcile.Interface, error) {
+	logging.Configure(c)
 	c.GetStringOption(OPT_IDENTIFIER)
+	// an invalid sharding must not result in an unsharded controller
+	if _, err := sharding.Get(config.Get(c.GetContext())); err != nil {
+		return nil, fmt.Errorf("invalid sharding: %s", err)
+	}
+	// without prefix the registry records would overwrite the TXT records of entries
+	if registry, _ := c.GetStringOption(OPT_TXT_REGISTRY); registry == dns.REGISTRY_EXTERNAL_DNS {
+		if prefix, _ := c.GetStringOption(OPT_EXTERNAL_DNS_PREFIX); prefix == "" {
+			return nil, fmt.Errorf("option --%s required for txt registry %q", OPT_EXTERNAL_DNS_PREFIX, registry)
+		}
+	}
 	return &reconciler{
 		controller: c,
 		state: c.GetOrCreateSharedValue(KEY_STATE,
@@ -110,6 +150,12 @@ func (this *reconciler) Command(logger logger.LogContext, cmd string) reconcile.
 	if zoneid != "" {
 		return this.state.ReconcileZone(logger, zoneid)
 	}
+	if cmd == CMD_REPORT {
+		return this.state.Report(logger)
+	}
+	if cmd == CMD_PROVIDER_HEALTH {
+		return this.state.CheckProviderHealth(logger)
+	}
 	logger.Infof("got unhandled command %q", cmd)
 	return reconcile.Succeeded(logger)
 }
@@ -132,7 +178,7 @@ func (this *reconciler) Delete(logger logger.LogContext, obj resources.Object) r
 	case obj.IsA(&api.DNSProvider{}):
 		return this.state.RemoveProvider(logger, dnsutils.DNSProvider(obj))
 	case obj.IsA(&api.DNSEntry{}):
-		//return this.state.UpdateEntry(logger, dnsutils.DNSEntry(obj))
+		return this.state.DeleteEntry(logger, dnsutils.DNSEntry(obj))
 	case obj.IsA(&corev1.Secret{}):
 		return this.state.UpdateSecret(logger, obj)
 	}
@@ -21,8 +21,10 @@ import (
 	"github.com/gardener/external-dns-management/pkg/dns"
 	"k8s.io/apimachinery/pkg/util/validation"
 	"net"
+	"regexp"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/gardener/controller-manager-library/pkg/controllermanager/controller/reconcile"
 	"github.com/gardener/controller-manager-library/pkg/logger"
@@ -30,9 +32,11 @@ import (
 	"github.com/gardener/controller-manager-library/pkg/utils"
 
 	api "github.com/gardener/external-dns-management/pkg/apis/dns/v1alpha1"
+	"github.com/gardener/external-dns-management/pkg/dns/metrics"
 	dnsutils "github.com/gardener/external-dns-management/pkg/dns/utils"
 
 	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 type Entry struct {
@@ -40,12 +44,25 @@ type Entry struct {
 	object    *dnsutils.DNSEntryObject
 	dnsname   string
 	targets   Targets
+	private   Targets
+	refs      []string
 	mappings  map[string][]string
 	ttl       *int64
 	interval  int64
+	health    time.Duration
+	probes    *healthState
+	// probed are the targets checked by the health check
+	probed    Targets
 	valid     bool
 	modified  bool
 	duplicate bool
+	// pending is the time a change of the entry has been detected,
+	// which is not yet applied, trace the id of the trace detecting it.
+	pending time.Time
+	trace   string
+	// diffs are the planned changes per record type last reported
+	// for the entry, they are reported again only if they change.
+	diffs map[string]string
 }
 
 func NewEntry(object *dnsutils.DNSEntryObject) *Entry {
@@ -54,6 +71,7 @@ func NewEntry(object *dnsutils.DNSEntryObject) *Entry {
 		dnsname:  object.DNSEntry().Spec.DNSName,
 		targets:  Targets{},
 		mappings: map[string][]string{},
+		probes:   newHealthState(),
 	}
 }
 
@@ -77,16 +95,54 @@ func (this *Entry) TTL() *int64 {
 	return this.ttl
 }
 
+// ProviderRef returns the name of the explicitly referenced provider
+// or nil.
+func (this *Entry) ProviderRef() resources.ObjectName {
+	return this.object.GetProviderRef()
+}
+
+// IsProtected reports whether the entry is annotated as protected.
+func (this *Entry) IsProtected() bool {
+	return this.object.GetAnnotations()[PROTECTED_ANNOTATION] == "true"
+}
+
 func (this *Entry) Interval() int64 {
 	return this.interval
 }
 
+// ProbeHealth starts probing the targets of the entry in the background.
+// done is called if the health of a target has changed.
+func (this *Entry) ProbeHealth(done func()) {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	if check := this.object.DNSEntry().Spec.HealthCheck; check != nil {
+		this.probes.probe(check, this.probed, done)
+	}
+}
+
+// HealthCheckInterval returns the interval for probing the targets
+// or 0 if no health check is configured.
+func (this *Entry) HealthCheckInterval() time.Duration {
+	return this.health
+}
+
 func (this *Entry) Targets() Targets {
 	this.lock.Lock()
 	defer this.lock.Unlock()
 	return this.targets
 }
 
+// TargetsForZone returns the targets to use for a public or
+// private hosted zone.
+func (this *Entry) TargetsForZone(private bool) Targets {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	if private && len(this.private) > 0 {
+		return this.private
+	}
+	return this.targets
+}
+
 func (this *Entry) IsValid() bool {
 	this.lock.Lock()
 	this.lock.Unlock()
@@ -99,6 +155,38 @@ func (this *Entry) IsModified() bool {
 	return this.modified
 }
 
+// setPending remembers the detection of a change of the entry for the
+// reconcile duration metrics, if no former change is still pending.
+func (this *Entry) setPending(traceid string) {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	if this.pending.IsZero() {
+		this.pending = time.Now()
+		this.trace = traceid
+	}
+}
+
+// observeReconcile records the duration since the detection of a pending
+// change for its final outcome. The change is not pending anymore.
+func (this *Entry) observeReconcile(ptype, outcome string) {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	if this.pending.IsZero() {
+		return
+	}
+	metrics.ObserveEntryReconcile(ptype, outcome, this.pending, this.trace)
+	this.pending = time.Time{}
+	this.trace = ""
+}
+
+var metadataKey = regexp.MustCompile("^[a-zA-Z0-9][a-zA-Z0-9./_-]*$")
+
+// Metadata returns the provider specific metadata for the records
+// of the entry.
+func (this *Entry) Metadata() map[string]string {
+	return this.object.DNSEntry().Spec.ProviderMetadata
+}
+
 func (this *Entry) Validate() (targets Targets, warnings []string, err error) {
 
 	spec := &this.object.DNSEntry().Spec
@@ -118,10 +206,27 @@ func (this *Entry) Validate() (targets Targets, warnings []string, err error) {
 		err =fmt.Errorf("%q is no valid dns name (%v)", check, errs)
 		return
 	}
-	if len(spec.Targets) > 0 && len(spec.Text) > 0 {
+	if (len(spec.Targets) > 0 || len(spec.TargetRefs) > 0) && len(spec.Text) > 0 {
 		err = fmt.Errorf("only Text or Targets possible", err)
 		return
 	}
+	if spec.HealthCheck != nil {
+		if err = validateHealthCheck(spec.HealthCheck); err != nil {
+			return
+		}
+	}
+	switch spec.DeletionPolicy {
+	case "", api.DELETION_POLICY_DELETE, api.DELETION_POLICY_ORPHAN:
+	default:
+		err = fmt.Errorf("invalid deletion policy %q", spec.DeletionPolicy)
+		return
+	}
+	for k, v := range spec.ProviderMetadata {
+		if !metadataKey.MatchString(k) || strings.ContainsAny(v, "\"\\") {
+			err = fmt.Errorf("invalid provider metadata %q", k)
+			return
+		}
+	}
 
 	this.ttl = spec.TTL
 	for _, t := range spec.Targets {
@@ -132,6 +237,12 @@ func (this *Entry) Validate() (targets Targets, warnings []string, err error) {
 			targets = append(targets, new)
 		}
 	}
+	for _, t := range this.refs {
+		new := NewTargetFromEntry(t, this)
+		if !targets.Has(new) {
+			targets = append(targets, new)
+		}
+	}
 	for _, t := range spec.Text {
 		new := NewText(t, this)
 		if targets.Has(new) {
@@ -142,7 +253,11 @@ func (this *Entry) Validate() (targets Targets, warnings []string, err error) {
 	}
 
 	if len(targets) == 0 {
-		err = fmt.Errorf("no target or text specified")
+		if len(spec.TargetRefs) > 0 && len(spec.Targets) == 0 {
+			err = fmt.Errorf("no targets found for referenced entries")
+		} else {
+			err = fmt.Errorf("no target or text specified")
+		}
 	}
 	return
 }
@@ -207,6 +322,7 @@ func (this *Entry) Update(logger logger.LogContext, object *dnsutils.DNSEntryObj
 	targets, warnings, verr := this.Validate()
 
 	if verr!=nil {
+		this.object.Event(corev1.EventTypeWarning, "invalid", verr.Error())
 		this.UpdateStatus(logger, api.STATE_INVALID, verr.Error())
 		return reconcile.Failed(logger, verr)
 	}
@@ -214,6 +330,15 @@ func (this *Entry) Update(logger logger.LogContext, object *dnsutils.DNSEntryObj
 	///////////// handle
 
 	targets, mappings := this.NormalizeTargets(logger, targets...)
+	this.health = 0
+	this.probed = nil
+	var failed map[string]error
+	if spec.HealthCheck != nil {
+		this.health = healthCheckInterval(spec.HealthCheck)
+		this.probed = targets
+		failed = this.probes.result(targets)
+		targets = healthyTargets(logger, targets, failed)
+	}
 	if len(mappings) > 0 {
 		if spec.CNameLookupInterval != nil && *spec.CNameLookupInterval > 0 {
 			this.interval = *spec.CNameLookupInterval
@@ -221,6 +346,17 @@ func (this *Entry) Update(logger logger.LogContext, object *dnsutils.DNSEntryObj
 			this.interval = 600
 		}
 	}
+	private := Targets{}
+	for _, t := range spec.PrivateTargets {
+		private = append(private, NewTargetFromEntry(t, this))
+	}
+	private, _ = this.NormalizeTargets(logger, private...)
+	if private.DifferFrom(this.private) {
+		logger.Infof("private targets changed")
+		this.modified = true
+		this.private = private
+	}
+
 	mod := resources.NewModificationState(this.object)
 	status := &this.object.DNSEntry().Status
 	if targets.DifferFrom(this.targets) {
@@ -251,7 +387,12 @@ func (this *Entry) Update(logger logger.LogContext, object *dnsutils.DNSEntryObj
 			mod.Modify(true)
 		}
 	}
+	if spec.HealthCheck != nil || len(status.UnhealthyTargets) > 0 {
+		this.reportHealth(logger, status, failed)
+		mod.AssureStringSet(&status.UnhealthyTargets, unhealthyTargets(failed))
+	}
 	mod.AssureStringPtrValue(&status.Zone, zoneid)
+	mod.AssureStringValue(&status.Reason, "")
 	if err != nil {
 		mod.AssureStringValue(&status.State, api.STATE_ERROR)
 		mod.AssureStringPtrValue(&status.Message, err.Error())
@@ -275,6 +416,25 @@ func (this *Entry) Update(logger logger.LogContext, object *dnsutils.DNSEntryObj
 	return reconcile.UpdateStatus(logger, mod.Update())
 }
 
+// reportHealth reports health transitions of the targets by events.
+func (this *Entry) reportHealth(logger logger.LogContext, status *api.DNSEntryStatus, failed map[string]error) {
+	old := utils.NewStringSetByArray(status.UnhealthyTargets)
+	for t, err := range failed {
+		if !old.Contains(t) {
+			msg := fmt.Sprintf("target %s failed health check: %s", t, err)
+			logger.Warn(msg)
+			this.object.Event(corev1.EventTypeWarning, "unhealthy", msg)
+		}
+	}
+	for t := range old {
+		if _, ok := failed[t]; !ok {
+			msg := fmt.Sprintf("target %s healthy again", t)
+			logger.Info(msg)
+			this.object.Event(corev1.EventTypeNormal, "healthy", msg)
+		}
+	}
+}
+
 func (this *Entry) targetList(targets Targets) ([]string, string) {
 	list := []string{}
 	msg := "update effective targets: "
@@ -290,6 +450,23 @@ func (this *Entry) targetList(targets Targets) ([]string, string) {
 }
 
 func (this *Entry) UpdateStatus(logger logger.LogContext, state string, msg string) error {
+	return this.updateStatus(logger, state, msg, nil)
+}
+
+// syncState describes the records applied to the provider for an entry.
+type syncState struct {
+	ttl         int64
+	fingerprint string
+}
+
+// updateStatus updates state and message of the entry and the description
+// of the applied records, if given.
+func (this *Entry) updateStatus(logger logger.LogContext, state string, msg string, sync *syncState) error {
+	return this.updateStatusWithReason(logger, state, "", msg, sync)
+}
+
+// updateStatusWithReason additionally sets the reason for the state.
+func (this *Entry) updateStatusWithReason(logger logger.LogContext, state, reason, msg string, sync *syncState) error {
 	f := func(data resources.ObjectData) (bool, error) {
 		o := data.(*api.DNSEntry)
 		if state == api.STATE_PENDING && o.Status.State != "" {
@@ -297,7 +474,19 @@ func (this *Entry) UpdateStatus(logger logger.LogContext, state string, msg stri
 		}
 
 		mod := &utils.ModificationState{}
+		if sync != nil {
+			if o.Status.LastSyncTime == nil || o.Status.Fingerprint != sync.fingerprint || o.Status.State != state {
+				now := metav1.Now()
+				o.Status.LastSyncTime = &now
+				mod.Modify(true)
+			}
+			mod.AssureStringValue(&o.Status.Fingerprint, sync.fingerprint)
+			if sync.ttl > 0 {
+				mod.AssureInt64PtrValue(&o.Status.TTL, sync.ttl)
+			}
+		}
 		mod.AssureStringValue(&o.Status.State, state)
+		mod.AssureStringValue(&o.Status.Reason, reason)
 		mod.AssureStringPtrValue(&o.Status.Message, msg)
 		if mod.IsModified() {
 			logger.Infof("update state of '%s/%s' to %s (%s)", o.Namespace, o.Name, state, msg)
@@ -319,18 +508,25 @@ func (this *Entry) NormalizeTargets(logger logger.LogContext, targets ...Target)
 
 	result := make(Targets, 0, len(targets))
 	mappings := map[string][]string{}
+	flatten := this.object.DNSEntry().Spec.Flatten
 	for _, t := range targets {
 		ty := t.GetRecordType()
-		if ty == dns.RS_CNAME && len(targets) > 1 {
+		if ty == dns.RS_CNAME && (len(targets) > 1 || flatten) {
 			addrs, err := net.LookupHost(t.GetHostName())
 			if err == nil {
-				for _, addr := range addrs {
-					result = append(result, NewTarget(dns.RS_A, addr, t.GetEntry()))
-				}
+				this.mappings[t.GetHostName()] = addrs
 			} else {
 				w := fmt.Sprintf("cannot lookup '%s': %s", t.GetHostName(), err)
 				logger.Warn(w)
 				this.object.Event(corev1.EventTypeNormal, "dnslookup", w)
+				if flatten {
+					// keep the addresses of the last successful lookup
+					// instead of dropping the records of the entry
+					addrs = this.mappings[t.GetHostName()]
+				}
+			}
+			for _, addr := range addrs {
+				result = append(result, NewTargetFromEntry(addr, t.GetEntry()))
 			}
 			mappings[t.GetHostName()] = addrs
 		} else {
@@ -351,18 +547,57 @@ func (this *Entry) Before(e *Entry) bool {
 
 type StatusUpdate struct {
 	*Entry
-	logger logger.LogContext
-	done   bool
+	logger  logger.LogContext
+	ptype   string
+	done    bool
+	actions []string
+	ttl     int64
+	ttlmsg  string
 }
 
-func NewStatusUpdate(logger logger.LogContext, e *Entry) DoneHandler {
-	return &StatusUpdate{Entry: e, logger: logger}
+func NewStatusUpdate(logger logger.LogContext, e *Entry, ptype string) DoneHandler {
+	return &StatusUpdate{Entry: e, logger: logger, ptype: ptype}
+}
+
+// addAction remembers a change request issued for the entry to
+// report it by an event, once the request has been executed.
+func (this *StatusUpdate) addAction(action, rtype string) {
+	this.actions = append(this.actions, fmt.Sprintf("%s %s", action, rtype))
+}
+
+// addDiff reports the planned changes of a record set of the entry
+// before they are submitted to the provider.
+func (this *StatusUpdate) addDiff(rtype, diff string) {
+	if diff == "" {
+		diff = "records unchanged"
+	}
+	this.lock.Lock()
+	if this.diffs == nil {
+		this.diffs = map[string]string{}
+	}
+	reported := this.diffs[rtype] == diff
+	this.diffs[rtype] = diff
+	this.lock.Unlock()
+	if reported {
+		return
+	}
+	this.logger.Infof("planned changes for %s record set %s: %s", rtype, this.dnsname, diff)
+	this.object.Eventf(corev1.EventTypeNormal, "diff", "planned changes for %s record set %s: %s", rtype, this.dnsname, diff)
+}
+
+// setTTL remembers the effective time-to-live of the entry's records
+// and the reason, if it differs from the requested one.
+func (this *StatusUpdate) setTTL(ttl int64, msg string) {
+	this.ttl = ttl
+	this.ttlmsg = msg
 }
 
 func (this *StatusUpdate) SetInvalid(err error) {
 	if !this.done {
 		this.done = true
 		this.modified = false
+		this.object.Event(corev1.EventTypeWarning, "invalid", err.Error())
+		this.observeReconcile(this.ptype, OUTCOME_INVALID)
 		err := this.UpdateStatus(this.logger, api.STATE_INVALID, err.Error())
 		if err != nil {
 			this.logger.Errorf("cannot update: %s", err)
@@ -373,17 +608,54 @@ func (this *StatusUpdate) Failed(err error) {
 	if !this.done {
 		this.done = true
 		this.modified = false
-		err := this.UpdateStatus(this.logger, api.STATE_ERROR, err.Error())
+		reason := ""
+		// the change is still pending and retried
+		metrics.AddEntryReconcileFailure(this.ptype, outcome(err))
+		if IsThrottlingError(err) {
+			reason = api.REASON_THROTTLED
+			this.object.Eventf(corev1.EventTypeWarning, "throttled", "request throttled by provider: %s", err)
+		} else {
+			this.object.Eventf(corev1.EventTypeWarning, "failed", "cannot %s record set(s): %s", strings.Join(this.actions, ", "), err)
+		}
+		err := this.updateStatusWithReason(this.logger, api.STATE_ERROR, reason, err.Error(), nil)
 		if err != nil {
 			this.logger.Errorf("cannot update: %s", err)
 		}
 	}
 }
+// Planned settles the status of an entry whose changes are only planned
+// by a provider in dry run mode.
+func (this *StatusUpdate) Planned() {
+	if !this.done {
+		this.done = true
+		this.modified = false
+		err := this.updateStatus(this.logger, api.STATE_READY, "dry run: record set changes planned, but not applied", nil)
+		if err != nil {
+			this.logger.Errorf("cannot update: %s", err)
+		}
+	}
+}
+
 func (this *StatusUpdate) Succeeded() {
 	if !this.done {
 		this.done = true
 		this.modified = false
-		err := this.UpdateStatus(this.logger, api.STATE_READY, "dns entry active")
+		msg := "dns entry active"
+		if len(this.actions) > 0 {
+			this.object.Eventf(corev1.EventTypeNormal, "applied", "record set(s) changed in provider: %s", strings.Join(this.actions, ", "))
+			if this.ttlmsg != "" {
+				this.object.Event(corev1.EventTypeWarning, "ttl", this.ttlmsg)
+			}
+		}
+		if this.ttlmsg != "" {
+			msg = fmt.Sprintf("%s (%s)", msg, this.ttlmsg)
+		}
+		this.observeReconcile(this.ptype, OUTCOME_SUCCESS)
+		this.lock.Lock()
+		this.diffs = nil
+		this.lock.Unlock()
+		applied := &syncState{ttl: this.ttl, fingerprint: fingerprint(this.ttl, this.Targets())}
+		err := this.updateStatus(this.logger, api.STATE_READY, msg, applied)
 		if err != nil {
 			this.logger.Errorf("cannot update: %s", err)
 		}
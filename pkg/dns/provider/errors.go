@@ -0,0 +1,47 @@
+/*
+ * Copyright 2019 SAP SE or an SAP affiliate company. All rights reserved. This file is licensed under the Apache Software License, v. 2 except as noted otherwise in the LICENSE file
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ *
+ */
+
+package provider
+
+// ThrottlingError is used by DNSHandlers to indicate that a request has been
+// rejected by the provider API because of rate limiting.
+type ThrottlingError struct {
+	error
+}
+
+func NewThrottlingError(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &ThrottlingError{err}
+}
+
+func IsThrottlingError(err error) bool {
+	_, ok := err.(*ThrottlingError)
+	return ok
+}
+
+// outcome classifies the result of a reconcilation for the metrics.
+func outcome(err error) string {
+	switch {
+	case err == nil:
+		return OUTCOME_SUCCESS
+	case IsThrottlingError(err):
+		return OUTCOME_THROTTLED
+	default:
+		return OUTCOME_ERROR
+	}
+}
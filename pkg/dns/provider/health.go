@@ -0,0 +1,226 @@
+/*
+ * Copyright 2019 SAP SE or an SAP affiliate company. All rights reserved. This file is licensed under the Apache Software License, v. 2 except as noted otherwise in the LICENSE file
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ *
+ */
+
+package provider
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gardener/controller-manager-library/pkg/logger"
+	"github.com/gardener/controller-manager-library/pkg/utils"
+
+	api "github.com/gardener/external-dns-management/pkg/apis/dns/v1alpha1"
+	"github.com/gardener/external-dns-management/pkg/dns"
+)
+
+const defaultHealthCheckInterval = 30 * time.Second
+const defaultHealthCheckTimeout = 5 * time.Second
+
+func validateHealthCheck(check *api.HealthCheck) error {
+	switch check.Type {
+	case api.HEALTHCHECK_HTTP, api.HEALTHCHECK_HTTPS:
+	case api.HEALTHCHECK_TCP:
+		if check.Port <= 0 {
+			return fmt.Errorf("health check of type tcp requires a port")
+		}
+	default:
+		return fmt.Errorf("invalid health check type %q", check.Type)
+	}
+	if check.Port < 0 || check.Port > 65535 {
+		return fmt.Errorf("invalid health check port %d", check.Port)
+	}
+	return nil
+}
+
+func healthCheckInterval(check *api.HealthCheck) time.Duration {
+	if check.Interval != nil && *check.Interval > 0 {
+		return time.Duration(*check.Interval) * time.Second
+	}
+	return defaultHealthCheckInterval
+}
+
+func healthCheckTimeout(check *api.HealthCheck) time.Duration {
+	if check.Timeout != nil && *check.Timeout > 0 {
+		return time.Duration(*check.Timeout) * time.Second
+	}
+	return defaultHealthCheckTimeout
+}
+
+// healthState keeps the result of the last probe of the targets of an
+// entry. The targets are probed in the background, so that the entry
+// reconcilation never waits for the probes.
+type healthState struct {
+	lock    sync.Mutex
+	running bool
+	time    time.Time
+	hosts   utils.StringSet
+	failed  map[string]error
+}
+
+func newHealthState() *healthState {
+	return &healthState{failed: map[string]error{}}
+}
+
+// result returns the failure reasons of the last probe for the given
+// targets.
+func (this *healthState) result(targets Targets) map[string]error {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	failed := map[string]error{}
+	for _, t := range targets {
+		if err, ok := this.failed[t.GetHostName()]; ok {
+			failed[t.GetHostName()] = err
+		}
+	}
+	return failed
+}
+
+// probe starts probing the targets in the background, if no probe is
+// running and the last result is older than the interval or has been
+// determined for other targets. done is called if the set of unhealthy
+// targets has changed.
+func (this *healthState) probe(check *api.HealthCheck, targets Targets, done func()) {
+	hosts := utils.StringSet{}
+	for _, t := range targets {
+		if t.GetRecordType() != dns.RS_TXT {
+			hosts.Add(t.GetHostName())
+		}
+	}
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	if this.running || (hosts.Equals(this.hosts) && time.Now().Sub(this.time) < healthCheckInterval(check)) {
+		return
+	}
+	this.running = true
+	go func() {
+		failed := probeTargets(check, hosts)
+		this.lock.Lock()
+		changed := !unhealthyTargets(failed).Equals(unhealthyTargets(this.failed))
+		this.failed = failed
+		this.hosts = hosts
+		this.time = time.Now()
+		this.running = false
+		this.lock.Unlock()
+		if changed {
+			done()
+		}
+	}()
+}
+
+// probeTargets probes the given hosts in parallel and returns the
+// failure reasons for the unhealthy ones.
+func probeTargets(check *api.HealthCheck, hosts utils.StringSet) map[string]error {
+	failed := map[string]error{}
+	lock := sync.Mutex{}
+	wg := sync.WaitGroup{}
+	for h := range hosts {
+		wg.Add(1)
+		go func(host string) {
+			defer wg.Done()
+			if err := probe(check, host); err != nil {
+				lock.Lock()
+				failed[host] = err
+				lock.Unlock()
+			}
+		}(h)
+	}
+	wg.Wait()
+	return failed
+}
+
+// healthyTargets returns the targets not failing the health check. Text
+// targets are not probed. If no target is healthy, all targets are kept,
+// because an empty record set would not improve the situation.
+func healthyTargets(logger logger.LogContext, targets Targets, failed map[string]error) Targets {
+	if len(failed) == 0 {
+		return targets
+	}
+	healthy := Targets{}
+	for _, t := range targets {
+		if _, ok := failed[t.GetHostName()]; !ok {
+			healthy = append(healthy, t)
+		}
+	}
+	if len(healthy) == 0 {
+		logger.Warnf("all targets unhealthy -> keeping all targets")
+		return targets
+	}
+	return healthy
+}
+
+func probe(check *api.HealthCheck, host string) error {
+	timeout := healthCheckTimeout(check)
+	port := check.Port
+	switch check.Type {
+	case api.HEALTHCHECK_TCP:
+		conn, err := net.DialTimeout("tcp", net.JoinHostPort(host, strconv.Itoa(port)), timeout)
+		if err != nil {
+			return err
+		}
+		return conn.Close()
+	default:
+		if port == 0 {
+			port = 80
+			if check.Type == api.HEALTHCHECK_HTTPS {
+				port = 443
+			}
+		}
+		url := fmt.Sprintf("%s://%s%s", check.Type, net.JoinHostPort(host, strconv.Itoa(port)), check.Path)
+		req, err := http.NewRequest(http.MethodGet, url, nil)
+		if err != nil {
+			return err
+		}
+		if check.Host != "" {
+			req.Host = check.Host
+		}
+		transport := &http.Transport{DisableKeepAlives: true}
+		if check.Host != "" {
+			// verify the certificate for the virtual host instead of the target
+			transport.TLSClientConfig = &tls.Config{ServerName: check.Host}
+		}
+		client := &http.Client{
+			Transport: transport,
+			Timeout:   timeout,
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+		if resp.StatusCode >= 400 {
+			return fmt.Errorf("status code %d", resp.StatusCode)
+		}
+		return nil
+	}
+}
+
+// unhealthyTargets returns the set of targets failing the health check.
+func unhealthyTargets(failed map[string]error) utils.StringSet {
+	set := utils.StringSet{}
+	for t := range failed {
+		set.Add(t)
+	}
+	return set
+}
@@ -0,0 +1,232 @@
+/*
+ * Copyright 2019 SAP SE or an SAP affiliate company. All rights reserved. This file is licensed under the Apache Software License, v. 2 except as noted otherwise in the LICENSE file
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ *
+ */
+
+package provider
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gardener/controller-manager-library/pkg/controllermanager/controller/reconcile"
+	"github.com/gardener/controller-manager-library/pkg/logger"
+	"github.com/gardener/controller-manager-library/pkg/resources"
+	"github.com/gardener/controller-manager-library/pkg/server"
+	"github.com/gardener/controller-manager-library/pkg/server/healthz"
+	"github.com/gardener/controller-manager-library/pkg/utils"
+)
+
+func init() {
+	server.Register("/readyz", readyzHandler)
+	server.Register("/healthz/providers", providersHealthzHandler)
+}
+
+// ProviderHealth is the result of the last check of the credentials
+// and hosted zones of a provider.
+type ProviderHealth struct {
+	Provider  string    `json:"provider"`
+	Type      string    `json:"type"`
+	Healthy   bool      `json:"healthy"`
+	Message   string    `json:"message,omitempty"`
+	LastCheck time.Time `json:"lastCheck"`
+}
+
+// ProviderTypeHealth summarizes the health of the providers of a type.
+// A type is ready if at least one of its providers is healthy.
+type ProviderTypeHealth struct {
+	Type      string            `json:"type"`
+	Ready     bool              `json:"ready"`
+	Providers []*ProviderHealth `json:"providers"`
+}
+
+// controllerReadiness keeps the provisioning controllers and whether
+// they have been started, which happens after their caches are synced
+// and their state has been set up. providerTypes are the provider types
+// of the controllers requiring a healthy provider for the readiness.
+var controllerReadiness = struct {
+	lock          sync.Mutex
+	started       map[string]bool
+	providerTypes utils.StringSet
+}{started: map[string]bool{}, providerTypes: utils.StringSet{}}
+
+func registerControllerReadiness(name string, ptype string, providers bool) {
+	controllerReadiness.lock.Lock()
+	defer controllerReadiness.lock.Unlock()
+	if _, ok := controllerReadiness.started[name]; !ok {
+		controllerReadiness.started[name] = false
+	}
+	if providers {
+		controllerReadiness.providerTypes.Add(ptype)
+	}
+}
+
+func setControllerStarted(name string) {
+	controllerReadiness.lock.Lock()
+	defer controllerReadiness.lock.Unlock()
+	controllerReadiness.started[name] = true
+}
+
+func getReadinessProviderTypes() utils.StringSet {
+	controllerReadiness.lock.Lock()
+	defer controllerReadiness.lock.Unlock()
+	return controllerReadiness.providerTypes.Copy()
+}
+
+func getPendingControllers() []string {
+	controllerReadiness.lock.Lock()
+	defer controllerReadiness.lock.Unlock()
+	result := []string{}
+	for name, started := range controllerReadiness.started {
+		if !started {
+			result = append(result, name)
+		}
+	}
+	sort.Strings(result)
+	return result
+}
+
+var providerHealth = struct {
+	lock   sync.Mutex
+	health map[string]*ProviderHealth
+}{health: map[string]*ProviderHealth{}}
+
+func setProviderHealth(ptype string, name resources.ObjectName, err error) {
+	providerHealth.lock.Lock()
+	defer providerHealth.lock.Unlock()
+	h := &ProviderHealth{Provider: name.String(), Type: ptype, Healthy: err == nil, LastCheck: time.Now()}
+	if err != nil {
+		h.Message = err.Error()
+	}
+	providerHealth.health[name.String()] = h
+}
+
+func removeProviderHealth(name resources.ObjectName) {
+	providerHealth.lock.Lock()
+	defer providerHealth.lock.Unlock()
+	delete(providerHealth.health, name.String())
+}
+
+func getProviderTypeHealth() []*ProviderTypeHealth {
+	providerHealth.lock.Lock()
+	defer providerHealth.lock.Unlock()
+	types := map[string]*ProviderTypeHealth{}
+	for _, h := range providerHealth.health {
+		t := types[h.Type]
+		if t == nil {
+			t = &ProviderTypeHealth{Type: h.Type}
+			types[h.Type] = t
+		}
+		c := *h
+		t.Providers = append(t.Providers, &c)
+		t.Ready = t.Ready || h.Healthy
+	}
+	result := []*ProviderTypeHealth{}
+	for _, t := range types {
+		sort.Slice(t.Providers, func(i, j int) bool { return t.Providers[i].Provider < t.Providers[j].Provider })
+		result = append(result, t)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Type < result[j].Type })
+	return result
+}
+
+// readyzHandler reports the controller manager as ready if it is healthy
+// and all provisioning controllers have been started. For controllers
+// with option --provider-readiness additionally at least one provider
+// of their provider type must be healthy.
+func readyzHandler(w http.ResponseWriter, r *http.Request) {
+	ok, info := healthz.HealthInfo()
+	if ok {
+		if pending := getPendingControllers(); len(pending) > 0 {
+			ok = false
+			info = "controllers not started: " + strings.Join(pending, ", ")
+		}
+	}
+	if ok {
+		types := getReadinessProviderTypes()
+		for _, t := range getProviderTypeHealth() {
+			if types.Contains(t.Type) && !t.Ready {
+				ok = false
+				info = "no healthy provider of type " + t.Type
+				break
+			}
+		}
+	}
+	if ok {
+		w.WriteHeader(http.StatusOK)
+	} else {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	w.Write([]byte(info))
+}
+
+// providersHealthzHandler reports the health of all providers. It fails
+// if any provider is unhealthy.
+func providersHealthzHandler(w http.ResponseWriter, r *http.Request) {
+	types := getProviderTypeHealth()
+	status := http.StatusOK
+	for _, t := range types {
+		for _, p := range t.Providers {
+			if !p.Healthy {
+				status = http.StatusServiceUnavailable
+			}
+		}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(types)
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// CMD_PROVIDER_HEALTH is the command used to check the providers
+// periodically.
+const CMD_PROVIDER_HEALTH = "providerhealth"
+
+// CheckProviderHealth lists the hosted zones of all providers handled by
+// this controller to detect expired or revoked credentials, even if the
+// provider objects are not changed.
+func (this *state) CheckProviderHealth(logger logger.LogContext) reconcile.Status {
+	if this.config.ProviderHealthInterval <= 0 {
+		return reconcile.Succeeded(logger)
+	}
+	this.lock.Lock()
+	providers := []*dnsProviderVersion{}
+	for _, p := range this.providers {
+		if p.handler != nil && p.isResponsible() {
+			providers = append(providers, p)
+		}
+	}
+	this.lock.Unlock()
+
+	for _, p := range providers {
+		_, err := p.handler.GetZones()
+		if err != nil {
+			err = fmt.Errorf("cannot get zones: %s", err)
+			logger.Warnf("health check of provider %s failed: %s", p.ObjectName(), err)
+		}
+		this.lock.Lock()
+		// skip providers deleted or updated in the meantime
+		if this.providers[p.ObjectName()] == p {
+			setProviderHealth(this.GetHandlerFactory().TypeCode(), p.ObjectName(), err)
+		}
+		this.lock.Unlock()
+	}
+	return reconcile.Succeeded(logger).RescheduleAfter(this.config.ProviderHealthInterval)
+}
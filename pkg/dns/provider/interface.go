@@ -18,22 +18,64 @@ package provider
 
 import (
 	"context"
-	"github.com/gardener/external-dns-management/pkg/dns"
+	"strings"
+	"time"
 
+	"github.com/gardener/controller-manager-library/pkg/controllermanager/config"
 	"github.com/gardener/controller-manager-library/pkg/controllermanager/controller"
 	"github.com/gardener/controller-manager-library/pkg/controllermanager/controller/reconcile"
 	"github.com/gardener/controller-manager-library/pkg/logger"
 	"github.com/gardener/controller-manager-library/pkg/resources"
 	"github.com/gardener/controller-manager-library/pkg/utils"
+	"github.com/gardener/external-dns-management/pkg/dns"
+	"github.com/gardener/external-dns-management/pkg/dns/sharding"
 	dnsutils "github.com/gardener/external-dns-management/pkg/dns/utils"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/util/flowcontrol"
 )
 
 type Config struct {
-	TTL     int64
-	Ident   string
-	Dryrun  bool
-	Factory DNSHandlerFactory
+	TTL              int64
+	MinTTL           int64
+	MaxTTL           int64
+	CacheTTL         time.Duration
+	BatchSize        int
+	CoalesceInterval time.Duration
+	ProviderWorkers  int
+	Sharding         sharding.Sharding
+	Ident            string
+	// AdoptOwners are the identifiers of other controllers whose
+	// records are taken over for the own entries, but never deleted.
+	AdoptOwners      utils.StringSet
+	Dryrun           bool
+	PropagationCheck bool
+	// PropagationResolvers are the name servers used for the
+	// propagation check, all of them must answer the actual records.
+	PropagationResolvers []string
+	PropagationTimeout   time.Duration
+	BackupInterval       time.Duration
+	BackupNamespace      string
+	// AuditSink receives the audit records for all applied
+	// changes (nil if auditing is disabled).
+	AuditSink AuditSink
+	// Backoff for the retries of failed zone reconcilations.
+	Backoff Backoff
+	// ReportInterval is the update interval of the DNSReport
+	// (0 if disabled).
+	ReportInterval time.Duration
+	// StaleRecords is the handling of record sets owned by the
+	// controller without entry (delete or report).
+	StaleRecords string
+	// StaleRecordGracePeriod is the time stale record sets are kept
+	// before they are deleted.
+	StaleRecordGracePeriod time.Duration
+	// ProviderHealthInterval is the interval for checking the
+	// providers (0 if disabled).
+	ProviderHealthInterval time.Duration
+	// ProviderReadiness requires a healthy provider for the
+	// readiness of the controller.
+	ProviderReadiness bool
+	Factory           DNSHandlerFactory
 }
 
 func NewConfigForController(c controller.Interface, factory DNSHandlerFactory) Config {
@@ -45,13 +87,124 @@ func NewConfigForController(c controller.Interface, factory DNSHandlerFactory) C
 	if err != nil {
 		ttl = 300
 	}
+	minttl, _ := c.GetIntOption(OPT_MIN_TTL)
+	maxttl, _ := c.GetIntOption(OPT_MAX_TTL)
+	cachettl, err := c.GetIntOption(OPT_CACHE_TTL)
+	if err != nil {
+		cachettl = 120
+	}
+	batchsize, _ := c.GetIntOption(OPT_BATCH_SIZE)
+	coalesce, _ := c.GetIntOption(OPT_COALESCE_INTERVAL)
+	providerworkers, err := c.GetIntOption(OPT_PROVIDER_ZONE_WORKERS)
+	if err != nil {
+		providerworkers = 1
+	}
+	// the sharding is validated when the reconciler is created
+	shards, _ := sharding.Get(config.Get(c.GetContext()))
+	registry, _ := c.GetStringOption(OPT_TXT_REGISTRY)
+	switch registry {
+	case dns.REGISTRY_DEFAULT, dns.REGISTRY_EXTERNAL_DNS:
+		dns.Registry = registry
+	case "":
+	default:
+		c.Errorf("invalid txt registry %q: using %q", registry, dns.Registry)
+	}
+	dns.ExternalDNSPrefix, _ = c.GetStringOption(OPT_EXTERNAL_DNS_PREFIX)
 	dryrun, _ := c.GetBoolOption(OPT_DRYRUN)
-	return Config{Ident: ident, Dryrun: dryrun, TTL: int64(ttl), Factory: factory}
+	propagation, _ := c.GetBoolOption(OPT_PROPAGATION_CHECK)
+	resolvers := []string{}
+	if s, _ := c.GetStringOption(OPT_PROPAGATION_RESOLVERS); s != "" {
+		for _, r := range strings.Split(s, ",") {
+			if r = strings.TrimSpace(r); r != "" {
+				if _, err := dns.NewResolver(r); err != nil {
+					c.Errorf("ignoring propagation resolver: %s", err)
+					continue
+				}
+				resolvers = append(resolvers, r)
+			}
+		}
+	}
+	propagationtimeout, _ := c.GetIntOption(OPT_PROPAGATION_TIMEOUT)
+	backupinterval, _ := c.GetIntOption(OPT_BACKUP_INTERVAL)
+	backupnamespace, err := c.GetStringOption(OPT_BACKUP_NAMESPACE)
+	if err != nil || backupnamespace == "" {
+		backupnamespace = "default"
+	}
+	backoffbase, _ := c.GetIntOption(OPT_BACKOFF_BASE)
+	if backoffbase <= 0 {
+		backoffbase = 5
+	}
+	backoffmax, _ := c.GetIntOption(OPT_BACKOFF_MAX)
+	if backoffmax < backoffbase {
+		backoffmax = backoffbase
+	}
+	backoffjitter, _ := c.GetIntOption(OPT_BACKOFF_JITTER)
+	if backoffjitter < 0 || backoffjitter > 100 {
+		c.Errorf("invalid backoff jitter %d: using 20", backoffjitter)
+		backoffjitter = 20
+	}
+	reportinterval, _ := c.GetIntOption(OPT_REPORT_INTERVAL)
+	auditspec, _ := c.GetStringOption(OPT_AUDIT_SINK)
+	audit, err := NewAuditSink(auditspec)
+	if err != nil {
+		c.Errorf("audit disabled: %s", err)
+	}
+	stalerecords, _ := c.GetStringOption(OPT_STALE_RECORDS)
+	switch stalerecords {
+	case STALE_RECORDS_DELETE, STALE_RECORDS_REPORT:
+	default:
+		c.Errorf("invalid stale record handling %q: using %q", stalerecords, STALE_RECORDS_DELETE)
+		stalerecords = STALE_RECORDS_DELETE
+	}
+	stalegrace, _ := c.GetIntOption(OPT_STALE_RECORD_GRACE_PERIOD)
+	healthinterval, _ := c.GetIntOption(OPT_PROVIDER_HEALTH_INTERVAL)
+	providerreadiness, _ := c.GetBoolOption(OPT_PROVIDER_READINESS)
+	adopt := utils.StringSet{}
+	if s, _ := c.GetStringOption(OPT_ADOPT_OWNERS); s != "" {
+		for _, o := range strings.Split(s, ",") {
+			if o = strings.TrimSpace(o); o != "" && o != ident {
+				adopt.Add(o)
+			}
+		}
+	}
+	return Config{
+		Ident:            ident,
+		AdoptOwners:      adopt,
+		Dryrun:           dryrun,
+		PropagationCheck: propagation,
+		TTL:              int64(ttl),
+		MinTTL:           int64(minttl),
+		MaxTTL:           int64(maxttl),
+		CacheTTL:         time.Duration(cachettl) * time.Second,
+		BatchSize:        batchsize,
+		CoalesceInterval: time.Duration(coalesce) * time.Second,
+		ProviderWorkers:  providerworkers,
+		Sharding:         shards,
+		Factory:          factory,
+
+		PropagationResolvers: resolvers,
+		PropagationTimeout:   time.Duration(propagationtimeout) * time.Second,
+		BackupInterval:       time.Duration(backupinterval) * time.Second,
+		BackupNamespace:      backupnamespace,
+		AuditSink:            audit,
+		Backoff: Backoff{
+			Base:   time.Duration(backoffbase) * time.Second,
+			Max:    time.Duration(backoffmax) * time.Second,
+			Jitter: float64(backoffjitter) / 100,
+		},
+		ReportInterval:         time.Duration(reportinterval) * time.Second,
+		StaleRecords:           stalerecords,
+		StaleRecordGracePeriod: time.Duration(stalegrace) * time.Second,
+		ProviderHealthInterval: time.Duration(healthinterval) * time.Second,
+		ProviderReadiness:      providerreadiness,
+	}
 }
 
 type DNSHostedZoneInfo struct {
 	Id     string
 	Domain string
+	// Private zones are only visible in private networks.
+	Private bool
 }
 
 type DNSHostedZoneInfos []*DNSHostedZoneInfo
@@ -63,7 +216,7 @@ func (this DNSHostedZoneInfos) equivalentTo(infos DNSHostedZoneInfos) bool {
 outer:
 	for _, i := range infos {
 		for _, t := range this {
-			if i.Id == t.Id && i.Domain == t.Domain {
+			if i.Id == t.Id && i.Domain == t.Domain && i.Private == t.Private {
 				continue outer
 			}
 			return false
@@ -77,6 +230,12 @@ type DNSHandlerConfig struct {
 	Config     *runtime.RawExtension
 	DryRun     bool
 	Context    context.Context
+	// MaxChangeCount limits the number of changes submitted with a single
+	// change request, 0 means the handler's default.
+	MaxChangeCount int
+	// RateLimiter must be used by the handler to limit the requests
+	// sent to the provider API. It is nil if no limit is configured.
+	RateLimiter flowcontrol.RateLimiter
 }
 
 type DNSHandler interface {
@@ -101,10 +260,36 @@ type DNSProvider interface {
 
 	GetZoneInfos() DNSHostedZoneInfos
 
-	GetDNSSets(string) (dns.DNSSets, error)
+	GetDNSSets(zoneid string, filter *ZoneFilter) (dns.DNSSets, error)
 
 	ExecuteRequests(logger logger.LogContext, zoneid string, requests []*ChangeRequest) error
+	// InvalidateZoneCache discards the cached state of a zone, if it
+	// has been modified by another provider.
+	InvalidateZoneCache(zoneid string)
+	// ReportPlan remembers the requests planned for a zone in dry run
+	// mode and returns the ones not planned before.
+	ReportPlan(zoneid string, plan utils.StringSet) utils.StringSet
 	Match(dns string) int
+
+	IsDryRun() bool
+	// TTLLimits returns the default, minimum and maximum
+	// time-to-live for records maintained by the provider.
+	TTLLimits() TTLLimits
+	// ZoneWorkers is the maximum number of concurrent zone
+	// reconcilations for the provider.
+	ZoneWorkers() int
+	// MaxEntries is the maximum number of entries accepted by
+	// the provider (0 for no limit).
+	MaxEntries() int
+	// DeletionPolicy is the policy for the deletion of the provider
+	// and the default for its entries.
+	DeletionPolicy() string
+	// IsProtected reports whether the records of all entries of the
+	// provider are protected.
+	IsProtected() bool
+	// SyncPeriod is the interval to resync the hosted zones of the
+	// provider, if explicitly configured for it (0 otherwise).
+	SyncPeriod() time.Duration
 }
 
 type DoneHandler interface {
@@ -117,6 +302,7 @@ type DNSState interface {
 	Setup()
 	Start()
 	GetConfig() Config
+	GetEntryCountForProvider(name resources.ObjectName) int
 	DecodeZoneCommand(name string) string
 	GetHandlerFactory() DNSHandlerFactory
 	GetController() controller.Interface
@@ -124,7 +310,13 @@ type DNSState interface {
 	UpdateProvider(logger logger.LogContext, obj *dnsutils.DNSProviderObject) reconcile.Status
 	UpdateSecret(logger logger.LogContext, obj resources.Object) reconcile.Status
 	UpdateEntry(logger logger.LogContext, object *dnsutils.DNSEntryObject) reconcile.Status
+	DeleteEntry(logger logger.LogContext, object *dnsutils.DNSEntryObject) reconcile.Status
 	ReconcileZone(logger logger.LogContext, zoneid string) reconcile.Status
+	// Report updates the DNSReport of the controller.
+	Report(logger logger.LogContext) reconcile.Status
+	// CheckProviderHealth checks the credentials and hosted zones
+	// of all providers.
+	CheckProviderHealth(logger logger.LogContext) reconcile.Status
 	RemoveProvider(logger logger.LogContext, obj *dnsutils.DNSProviderObject) reconcile.Status
 	ProviderDeleted(logger logger.LogContext, key resources.ObjectKey) reconcile.Status
 	EntryDeleted(logger logger.LogContext, key resources.ObjectKey) reconcile.Status
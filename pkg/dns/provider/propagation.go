@@ -0,0 +1,190 @@
+/*
+ * Copyright 2019 SAP SE or an SAP affiliate company. All rights reserved. This file is licensed under the Apache Software License, v. 2 except as noted otherwise in the LICENSE file
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ *
+ */
+
+package provider
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gardener/controller-manager-library/pkg/logger"
+	"github.com/gardener/controller-manager-library/pkg/resources"
+	"github.com/gardener/controller-manager-library/pkg/utils"
+
+	api "github.com/gardener/external-dns-management/pkg/apis/dns/v1alpha1"
+	"github.com/gardener/external-dns-management/pkg/dns"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const propagationCheckInterval = 30 * time.Second
+const propagationTimeoutCheckInterval = 5 * time.Minute
+const propagationLookupTimeout = 5 * time.Second
+const propagationStatusRefreshInterval = 10 * time.Minute
+
+// fingerprint returns a hash for the records maintained for an entry.
+func fingerprint(ttl int64, targets Targets) string {
+	lines := []string{}
+	for _, t := range targets {
+		lines = append(lines, fmt.Sprintf("%s %s", t.GetRecordType(), t.GetHostName()))
+	}
+	sort.Strings(lines)
+	h := sha256.New()
+	fmt.Fprintf(h, "%d\n%s", ttl, strings.Join(lines, "\n"))
+	return fmt.Sprintf("%x", h.Sum(nil))[:16]
+}
+
+// CheckPropagation resolves the dns name of a ready entry with all
+// configured resolvers and records the result in the entry status.
+// It returns the interval for the next check or 0 if the actual records
+// have been observed.
+func (this *Entry) CheckPropagation(logger logger.LogContext, config *Config) time.Duration {
+	status := this.object.Status()
+	if status.State != api.STATE_READY {
+		return 0
+	}
+	p := status.Propagation
+	if p != nil && p.State == api.PROPAGATION_PROPAGATED && p.Fingerprint == status.Fingerprint {
+		return 0
+	}
+
+	state := api.PROPAGATION_PROPAGATED
+	msg := "dns name resolves to the actual records"
+	interval := time.Duration(0)
+	if err := checkResolvers(config.PropagationResolvers, this.DNSName(), this.Targets()); err != nil {
+		state = api.PROPAGATION_PENDING
+		msg = err.Error()
+		interval = propagationCheckInterval
+		if config.PropagationTimeout > 0 && status.LastSyncTime != nil && time.Since(status.LastSyncTime.Time) > config.PropagationTimeout {
+			state = api.PROPAGATION_TIMEOUT
+			msg = fmt.Sprintf("not propagated after %s: %s", config.PropagationTimeout, msg)
+			interval = propagationTimeoutCheckInterval
+		}
+	}
+	fp := status.Fingerprint
+	f := func(data resources.ObjectData) (bool, error) {
+		o := data.(*api.DNSEntry)
+		if o.Status.Propagation == nil {
+			o.Status.Propagation = &api.PropagationStatus{}
+		}
+		p := o.Status.Propagation
+		mod := &utils.ModificationState{}
+		mod.AssureStringValue(&p.State, state)
+		mod.AssureStringPtrValue(&p.Message, msg)
+		mod.AssureStringValue(&p.Fingerprint, fp)
+		// an unchanged result is written only occasionally to avoid
+		// a status update for every check.
+		if mod.IsModified() || time.Since(p.LastCheckTime.Time) > propagationStatusRefreshInterval {
+			p.LastCheckTime = metav1.Now()
+			mod.Modify(true)
+		}
+		return mod.IsModified(), nil
+	}
+	if _, err := this.object.Modify(f); err != nil {
+		logger.Warnf("cannot update propagation status: %s", err)
+	}
+	if state != api.PROPAGATION_PROPAGATED {
+		logger.Infof("records not yet propagated: %s", msg)
+	}
+	return interval
+}
+
+// checkResolvers checks the answers of all given name servers. Without
+// name servers the system resolver is used.
+func checkResolvers(servers []string, dnsname string, targets Targets) error {
+	if len(servers) == 0 {
+		return lookupTargets(net.DefaultResolver, dnsname, targets)
+	}
+	for _, s := range servers {
+		resolver, err := dns.NewResolver(s)
+		if err == nil {
+			err = lookupTargets(resolver, dnsname, targets)
+		}
+		if err != nil {
+			return fmt.Errorf("resolver %s: %s", s, err)
+		}
+	}
+	return nil
+}
+
+// lookupTargets resolves a dns name and checks whether the answers match
+// the given targets.
+func lookupTargets(resolver *net.Resolver, dnsname string, targets Targets) error {
+	if strings.HasPrefix(dnsname, "*.") {
+		// any name matching the wildcard must be resolved accordingly
+		dnsname = "wildcard-propagation-check" + dnsname[1:]
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), propagationLookupTimeout)
+	defer cancel()
+
+	expected := map[string]utils.StringSet{}
+	for _, t := range targets {
+		set := expected[t.GetRecordType()]
+		if set == nil {
+			set = utils.StringSet{}
+			expected[t.GetRecordType()] = set
+		}
+		if ip := net.ParseIP(t.GetHostName()); ip != nil {
+			set.Add(ip.String())
+		} else {
+			set.Add(t.GetHostName())
+		}
+	}
+	for ty, set := range expected {
+		found := utils.StringSet{}
+		switch ty {
+		case dns.RS_A, dns.RS_AAAA:
+			addrs, err := resolver.LookupIPAddr(ctx, dnsname)
+			if err != nil {
+				return err
+			}
+			for _, addr := range addrs {
+				if addressRecordType(addr.IP) == ty {
+					found.Add(addr.IP.String())
+				}
+			}
+		case dns.RS_CNAME:
+			cname, err := resolver.LookupCNAME(ctx, dnsname)
+			if err != nil {
+				return err
+			}
+			found.Add(dns.NormalizeHostname(cname))
+			set = utils.StringSet{}
+			for t := range expected[ty] {
+				set.Add(dns.NormalizeHostname(t))
+			}
+		case dns.RS_TXT:
+			txts, err := resolver.LookupTXT(ctx, dnsname)
+			if err != nil {
+				return err
+			}
+			for _, t := range txts {
+				found.Add(dns.QuoteText(t))
+			}
+		default:
+			continue
+		}
+		if !found.Equals(set) {
+			return fmt.Errorf("%s records for %s: found %s, expected %s", ty, dnsname, found, set)
+		}
+	}
+	return nil
+}
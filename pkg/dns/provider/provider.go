@@ -20,8 +20,14 @@ import (
 	"fmt"
 	"github.com/gardener/external-dns-management/pkg/dns"
 	"k8s.io/apimachinery/pkg/runtime"
+	"reflect"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
 
 	api "github.com/gardener/external-dns-management/pkg/apis/dns/v1alpha1"
+	"github.com/gardener/external-dns-management/pkg/dns/metrics"
 	dnsutils "github.com/gardener/external-dns-management/pkg/dns/utils"
 
 	"github.com/gardener/controller-manager-library/pkg/controllermanager/controller/reconcile"
@@ -31,6 +37,8 @@ import (
 
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/flowcontrol"
 )
 
 func (this DNSProviders) LookupFor(dns string) DNSProvider {
@@ -38,15 +46,24 @@ func (this DNSProviders) LookupFor(dns string) DNSProvider {
 	match := -1
 	for _, p := range this {
 		n := p.Match(dns)
-		if n > 0 {
-			if match < n {
-				found = p
-			}
+		if n > 0 && preferProvider(n, p, match, found) {
+			found = p
+			match = n
 		}
 	}
 	return found
 }
 
+// preferProvider decides between two matching providers. The provider
+// with the longer domain match wins, for equal matches the provider name
+// is used to get a deterministic result.
+func preferProvider(n int, p DNSProvider, match int, found DNSProvider) bool {
+	if n != match {
+		return n > match
+	}
+	return p.ObjectName().String() < found.ObjectName().String()
+}
+
 type dnsProvider struct {
 	*dnsProviderVersion
 }
@@ -60,6 +77,7 @@ type dnsProviderVersion struct {
 
 	object  *dnsutils.DNSProviderObject
 	handler DNSHandler
+	cache   *zoneCache
 
 	config      utils.Properties
 	secret      resources.ObjectName
@@ -70,6 +88,21 @@ type dnsProviderVersion struct {
 
 	included utils.StringSet
 	excluded utils.StringSet
+
+	dryrun      bool
+	batchsize   int
+	ratelimit   *api.RateLimit
+	zoneworkers int
+	ttllimits   TTLLimits
+	syncperiod  time.Duration
+	resync      string
+	// plans are the requests last planned per zone in dry run mode.
+	plans *plannedRequests
+}
+
+type plannedRequests struct {
+	lock  sync.Mutex
+	zones map[string]utils.StringSet
 }
 
 func (this *dnsProviderVersion) equivalentTo(v *dnsProviderVersion) bool {
@@ -95,6 +128,12 @@ func (this *dnsProviderVersion) equivalentTo(v *dnsProviderVersion) bool {
 	if this.modified(v.object.DNSProvider().Spec.ProviderConfig) {
 		return false
 	}
+	if this.dryrun != v.dryrun {
+		return false
+	}
+	if !reflect.DeepEqual(this.object.DNSProvider().Spec.DomainAllowlists, v.object.DNSProvider().Spec.DomainAllowlists) {
+		return false
+	}
 	return true
 }
 
@@ -108,6 +147,14 @@ func updateDNSProvider(logger logger.LogContext, state DNSState, provider *dnsut
 
 		included: utils.StringSet{},
 		excluded: utils.StringSet{},
+
+		dryrun:      isDryRun(state, provider),
+		batchsize:   batchSize(logger, state, provider),
+		ratelimit:   provider.DNSProvider().Spec.RateLimit,
+		zoneworkers: providerZoneWorkers(logger, state, provider),
+		syncperiod:  providerSyncPeriod(state, provider),
+		resync:      provider.GetAnnotations()[RESYNC_ANNOTATION],
+		plans:       &plannedRequests{zones: map[string]utils.StringSet{}},
 	}
 
 	if last != nil && last.ObjectName() != this.ObjectName() {
@@ -117,6 +164,12 @@ func updateDNSProvider(logger logger.LogContext, state DNSState, provider *dnsut
 	var props utils.Properties
 	var err error
 
+	cfg := state.GetConfig()
+	this.ttllimits, err = newTTLLimits(&cfg, &provider.DNSProvider().Spec)
+	if err != nil {
+		return this, this.failed(logger, false, err, false)
+	}
+
 	ref := this.object.DNSProvider().Spec.SecretRef
 	if ref != nil {
 
@@ -141,19 +194,28 @@ func updateDNSProvider(logger logger.LogContext, state DNSState, provider *dnsut
 
 	this.config = props
 
-	if last == nil || !last.config.Equals(props) || this.modified(provider.DNSProvider().Spec.ProviderConfig) {
+	if last == nil || !last.config.Equals(props) || this.modified(provider.DNSProvider().Spec.ProviderConfig) || last.batchsize != this.batchsize ||
+		!sameRateLimit(last.ratelimit, this.ratelimit) {
 		cfg := DNSHandlerConfig{
-			Context:    this.state.GetController().GetContext(),
-			Properties: props,
-			Config:     provider.DNSProvider().Spec.ProviderConfig,
-			DryRun:     state.GetConfig().Dryrun,
+			Context:        this.state.GetController().GetContext(),
+			Properties:     props,
+			Config:         provider.DNSProvider().Spec.ProviderConfig,
+			DryRun:         state.GetConfig().Dryrun,
+			MaxChangeCount: this.batchsize,
+			RateLimiter:    newRateLimiter(this.ratelimit),
 		}
 		this.handler, err = state.GetHandlerFactory().Create(logger, &cfg)
 		if err != nil {
 			return nil, reconcile.Delay(logger, err)
 		}
+		this.cache = newZoneCache(this.handler, this.syncperiod)
 	} else {
 		this.handler = last.handler
+		this.cache = last.cache
+		this.plans = last.plans
+		if last.syncperiod != this.syncperiod {
+			this.cache = newZoneCache(this.handler, this.syncperiod)
+		}
 	}
 
 	dspec := provider.DNSProvider().Spec.Domains
@@ -206,6 +268,65 @@ func updateDNSProvider(logger logger.LogContext, state DNSState, provider *dnsut
 	return this, this.succeeded(logger, this.object.SetDomains(included, excluded))
 }
 
+func isDryRun(state DNSState, provider *dnsutils.DNSProviderObject) bool {
+	if state.GetConfig().Dryrun {
+		return true
+	}
+	a := provider.GetAnnotations()[DRYRUN_ANNOTATION]
+	return a == "true"
+}
+
+// providerSyncPeriod returns the interval to completely resync the hosted
+// zones of a provider. Without explicit setting it is the cache ttl.
+func providerSyncPeriod(state DNSState, provider *dnsutils.DNSProviderObject) time.Duration {
+	if s := provider.DNSProvider().Spec.SyncInterval; s != nil && *s > 0 {
+		return time.Duration(*s) * time.Second
+	}
+	return state.GetConfig().CacheTTL
+}
+
+func batchSize(logger logger.LogContext, state DNSState, provider *dnsutils.DNSProviderObject) int {
+	a := provider.GetAnnotations()[BATCH_SIZE_ANNOTATION]
+	if a != "" {
+		size, err := strconv.Atoi(a)
+		if err == nil && size >= 0 {
+			return size
+		}
+		logger.Warnf("invalid change batch size %q for provider %s", a, provider.ObjectName())
+	}
+	return state.GetConfig().BatchSize
+}
+
+func providerZoneWorkers(logger logger.LogContext, state DNSState, provider *dnsutils.DNSProviderObject) int {
+	a := provider.GetAnnotations()[ZONE_WORKERS_ANNOTATION]
+	if a != "" {
+		n, err := strconv.Atoi(a)
+		if err == nil && n >= 0 {
+			return n
+		}
+		logger.Warnf("invalid number of zone workers %q for provider %s", a, provider.ObjectName())
+	}
+	return state.GetConfig().ProviderWorkers
+}
+
+func newRateLimiter(limit *api.RateLimit) flowcontrol.RateLimiter {
+	if limit == nil || limit.RequestsPerSecond <= 0 {
+		return nil
+	}
+	burst := limit.Burst
+	if burst <= 0 {
+		burst = 1
+	}
+	return flowcontrol.NewTokenBucketRateLimiter(float32(limit.RequestsPerSecond), burst)
+}
+
+func sameRateLimit(a, b *api.RateLimit) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
 func (this *dnsProviderVersion) ObjectName() resources.ObjectName {
 	return this.object.ObjectName()
 }
@@ -230,6 +351,44 @@ func (this *dnsProviderVersion) GetExcludedDomains() utils.StringSet {
 	return this.excluded.Copy()
 }
 
+func (this *dnsProviderVersion) ZoneWorkers() int {
+	return this.zoneworkers
+}
+
+func (this *dnsProviderVersion) MaxEntries() int {
+	quota := this.object.DNSProvider().Spec.Quota
+	if quota == nil {
+		return 0
+	}
+	return quota.MaxEntries
+}
+
+func (this *dnsProviderVersion) IsProtected() bool {
+	return this.object.GetAnnotations()[PROTECTED_ANNOTATION] == "true"
+}
+
+func (this *dnsProviderVersion) DeletionPolicy() string {
+	if this.object.DNSProvider().Spec.DeletionPolicy == api.DELETION_POLICY_ORPHAN {
+		return api.DELETION_POLICY_ORPHAN
+	}
+	return api.DELETION_POLICY_DELETE
+}
+
+func (this *dnsProviderVersion) TTLLimits() TTLLimits {
+	return this.ttllimits
+}
+
+func (this *dnsProviderVersion) SyncPeriod() time.Duration {
+	if s := this.object.DNSProvider().Spec.SyncInterval; s == nil || *s <= 0 {
+		return 0
+	}
+	return this.syncperiod
+}
+
+func (this *dnsProviderVersion) IsDryRun() bool {
+	return this.dryrun
+}
+
 func (this *dnsProviderVersion) Match(dns string) int {
 	ilen := dnsutils.MatchSet(dns, this.included)
 	elen := dnsutils.MatchSet(dns, this.excluded)
@@ -255,7 +414,18 @@ func (this *dnsProviderVersion) modified(new *runtime.RawExtension) bool {
 	return true
 }
 
+func (this *dnsProviderVersion) isResponsible() bool {
+	return this.state.GetConfig().Sharding.IsResponsibleFor(this.ObjectName().String())
+}
+
 func (this *dnsProviderVersion) setError(modified bool, err error) error {
+	if !this.isResponsible() {
+		return nil
+	}
+	setProviderHealth(this.state.GetHandlerFactory().TypeCode(), this.ObjectName(), err)
+	if this.object.Status().State != api.STATE_ERROR {
+		this.object.Eventf(corev1.EventTypeWarning, "reconcile", "provider failed: %s", err)
+	}
 	modified = modified || this.object.SetDomains(utils.StringSet{}, utils.StringSet{})
 	modified = modified || this.object.SetState(api.STATE_ERROR, err.Error())
 	if modified {
@@ -284,17 +454,127 @@ func (this *dnsProviderVersion) failed(logger logger.LogContext, modified bool,
 }
 
 func (this *dnsProviderVersion) succeeded(logger logger.LogContext, modified bool) reconcile.Status {
+	if !this.isResponsible() {
+		return reconcile.Succeeded(logger)
+	}
+	setProviderHealth(this.state.GetHandlerFactory().TypeCode(), this.ObjectName(), nil)
 	status := &this.object.DNSProvider().Status
+	if status.State != api.STATE_READY {
+		this.object.Eventf(corev1.EventTypeNormal, "reconcile", "provider operational for domains %s", this.included)
+	}
 	mod := resources.NewModificationState(this.object, modified)
 	mod.AssureStringValue(&status.State, api.STATE_READY)
-	mod.AssureStringPtrValue(&status.Message, "provider operational")
+	mod.AssureIntPtrValue(&status.Entries, this.state.GetEntryCountForProvider(this.ObjectName()))
+	if this.dryrun {
+		mod.AssureStringPtrValue(&status.Message, "provider operational (dry run)")
+	} else {
+		mod.AssureStringPtrValue(&status.Message, "provider operational")
+	}
 	return reconcile.UpdateStatus(logger, mod.Update())
 }
 
-func (this *dnsProviderVersion) GetDNSSets(zoneid string) (dns.DNSSets, error) {
-	return this.handler.GetDNSSets(zoneid)
+// updateUsage publishes the number of entries handled by the provider
+// in its status.
+func (this *dnsProviderVersion) updateUsage(logger logger.LogContext, count int) {
+	if !this.isResponsible() {
+		return
+	}
+	f := func(data resources.ObjectData) (bool, error) {
+		p := data.(*api.DNSProvider)
+		mod := &utils.ModificationState{}
+		mod.AssureIntPtrValue(&p.Status.Entries, count)
+		return mod.IsModified(), nil
+	}
+	_, err := this.object.Modify(f)
+	if err != nil {
+		logger.Warnf("cannot update usage of provider %s: %s", this.ObjectName(), err)
+	}
+}
+
+// updateStaleRecords publishes the stale record sets kept in a hosted
+// zone in the provider status.
+func (this *dnsProviderVersion) updateStaleRecords(logger logger.LogContext, zoneid string, stale map[string]time.Time) {
+	if !this.isResponsible() {
+		return
+	}
+	f := func(data resources.ObjectData) (bool, error) {
+		p := data.(*api.DNSProvider)
+		records := []api.StaleRecord{}
+		for _, r := range p.Status.StaleRecords {
+			if r.Zone != zoneid {
+				records = append(records, r)
+			}
+		}
+		for n, t := range stale {
+			records = append(records, api.StaleRecord{DNSName: n, Zone: zoneid, Since: metav1.NewTime(t.Truncate(time.Second))})
+		}
+		sort.Slice(records, func(i, j int) bool {
+			if records[i].Zone != records[j].Zone {
+				return records[i].Zone < records[j].Zone
+			}
+			return records[i].DNSName < records[j].DNSName
+		})
+		if sameStaleRecords(p.Status.StaleRecords, records) {
+			return false, nil
+		}
+		p.Status.StaleRecords = records
+		if len(records) == 0 {
+			p.Status.StaleRecords = nil
+		}
+		return true, nil
+	}
+	_, err := this.object.Modify(f)
+	if err != nil {
+		logger.Warnf("cannot update stale records of provider %s: %s", this.ObjectName(), err)
+	}
+}
+
+func sameStaleRecords(a, b []api.StaleRecord) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i].DNSName != b[i].DNSName || a[i].Zone != b[i].Zone || !a[i].Since.Equal(&b[i].Since) {
+			return false
+		}
+	}
+	return true
+}
+
+func (this *dnsProviderVersion) GetDNSSets(zoneid string, filter *ZoneFilter) (dns.DNSSets, error) {
+	sets, hit, err := this.cache.GetDNSSets(zoneid, filter)
+	metrics.AddZoneCacheAccess(this.object.DNSProvider().Spec.Type, zoneid, hit)
+	return sets, err
 }
 
 func (this *dnsProviderVersion) ExecuteRequests(logger logger.LogContext, zoneid string, reqs []*ChangeRequest) error {
-	return this.handler.ExecuteRequests(logger, zoneid, reqs)
+	failed := false
+	for _, r := range reqs {
+		r.Done = &executionResult{DoneHandler: r.Done, failed: &failed}
+	}
+	err := this.handler.ExecuteRequests(logger, zoneid, reqs)
+	if !this.IsDryRun() {
+		this.cache.Executed(logger, zoneid, reqs, failed || err != nil)
+	}
+	return err
+}
+
+func (this *dnsProviderVersion) ReportPlan(zoneid string, plan utils.StringSet) utils.StringSet {
+	this.plans.lock.Lock()
+	defer this.plans.lock.Unlock()
+	added := utils.StringSet{}
+	last := this.plans.zones[zoneid]
+	for r := range plan {
+		if !last.Contains(r) {
+			added.Add(r)
+		}
+	}
+	this.plans.zones[zoneid] = plan
+	return added
+}
+
+func (this *dnsProviderVersion) InvalidateZoneCache(zoneid string) {
+	if this.cache != nil {
+		this.cache.InvalidateZone(zoneid)
+	}
 }
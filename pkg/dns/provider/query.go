@@ -0,0 +1,258 @@
+/*
+ * Copyright 2019 SAP SE or an SAP affiliate company. All rights reserved. This file is licensed under the Apache Software License, v. 2 except as noted otherwise in the LICENSE file
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ *
+ */
+
+package provider
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gardener/controller-manager-library/pkg/controllermanager/config"
+	"github.com/gardener/controller-manager-library/pkg/resources"
+	"github.com/gardener/controller-manager-library/pkg/server"
+
+	"github.com/gardener/external-dns-management/pkg/dns"
+)
+
+// OPT_QUERY_TOKEN_FILE enables the read-only query api for the controller
+// state. Requests must provide the content of the file as bearer token.
+const OPT_QUERY_TOKEN_FILE = "query-token-file"
+
+const queryPath = "/dns/zones"
+
+func init() {
+	config.RegisterExtension(func(cfg *config.Config) {
+		opt, _ := cfg.AddStringOption(OPT_QUERY_TOKEN_FILE)
+		opt.Description = "file with the bearer token required for the read-only query api (api disabled if not set)"
+	})
+	server.Register(queryPath, queryHandler)
+	server.Register(queryPath+"/", queryHandler)
+}
+
+// queryStates keeps the states of the provisioning controllers served
+// by the query api.
+var queryStates = struct {
+	lock      sync.Mutex
+	tokenfile string
+	states    map[string]*state
+}{states: map[string]*state{}}
+
+func registerQueryState(s *state) {
+	queryStates.lock.Lock()
+	defer queryStates.lock.Unlock()
+	if cfg := config.Get(s.controller.GetContext()); cfg != nil {
+		if o := cfg.GetOption(OPT_QUERY_TOKEN_FILE); o != nil {
+			queryStates.tokenfile = o.StringValue()
+		}
+	}
+	queryStates.states[s.controller.GetName()] = s
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+type ZoneInfo struct {
+	Controller string   `json:"controller"`
+	Zone       string   `json:"zone"`
+	Domain     string   `json:"domain"`
+	Private    bool     `json:"private,omitempty"`
+	Providers  []string `json:"providers"`
+	Entries    int      `json:"entries"`
+	Queued     bool     `json:"queued,omitempty"`
+}
+
+type ZoneState struct {
+	ZoneInfo
+	// CacheTime is the time the cached state has been read from the
+	// provider. It is not set, if there is no cached state.
+	CacheTime *time.Time              `json:"cacheTime,omitempty"`
+	Complete  bool                    `json:"complete,omitempty"`
+	Records   map[string]*RecordsInfo `json:"records,omitempty"`
+	Pending   []*EntryInfo            `json:"pending,omitempty"`
+}
+
+type RecordsInfo struct {
+	Owner string                    `json:"owner,omitempty"`
+	Sets  map[string]*RecordSetInfo `json:"sets"`
+}
+
+type RecordSetInfo struct {
+	TTL     int64    `json:"ttl"`
+	Records []string `json:"records"`
+}
+
+type EntryInfo struct {
+	Name    string   `json:"name"`
+	DNSName string   `json:"dnsName"`
+	Targets []string `json:"targets,omitempty"`
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+func queryHandler(w http.ResponseWriter, r *http.Request) {
+	queryStates.lock.Lock()
+	tokenfile := queryStates.tokenfile
+	states := []*state{}
+	for _, s := range queryStates.states {
+		states = append(states, s)
+	}
+	queryStates.lock.Unlock()
+
+	if tokenfile == "" {
+		http.NotFound(w, r)
+		return
+	}
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if !authorized(r, tokenfile) {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	zoneid := strings.Trim(strings.TrimPrefix(r.URL.Path, queryPath), "/")
+	var result interface{}
+	if zoneid == "" {
+		infos := []*ZoneInfo{}
+		for _, s := range states {
+			infos = append(infos, s.getZoneInfos()...)
+		}
+		sort.Slice(infos, func(i, j int) bool { return infos[i].Zone < infos[j].Zone })
+		result = infos
+	} else {
+		for _, s := range states {
+			if z := s.getZoneState(zoneid); z != nil {
+				result = z
+				break
+			}
+		}
+		if result == nil {
+			http.NotFound(w, r)
+			return
+		}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+func authorized(r *http.Request, tokenfile string) bool {
+	data, err := ioutil.ReadFile(tokenfile)
+	if err != nil {
+		return false
+	}
+	token := strings.TrimSpace(string(data))
+	auth := r.Header.Get("Authorization")
+	if token == "" || !strings.HasPrefix(auth, "Bearer ") {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(strings.TrimPrefix(auth, "Bearer ")), []byte(token)) == 1
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+func (this *state) getZoneInfos() []*ZoneInfo {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	infos := []*ZoneInfo{}
+	for zoneid := range this.zones {
+		infos = append(infos, this.getZoneInfo(zoneid))
+	}
+	return infos
+}
+
+func (this *state) getZoneInfo(zoneid string) *ZoneInfo {
+	zone := this.zones[zoneid]
+	info := &ZoneInfo{
+		Controller: this.controller.GetName(),
+		Zone:       zoneid,
+		Domain:     zone.Domain(),
+		Private:    zone.IsPrivate(),
+		Providers:  []string{},
+		Entries:    len(this.addEntriesForDomain(Entries{}, zone.Domain())),
+		Queued:     this.workers.IsQueued(zoneid),
+	}
+	for n := range this.zoneproviders[zoneid] {
+		info.Providers = append(info.Providers, n.String())
+	}
+	sort.Strings(info.Providers)
+	return info
+}
+
+// getZoneState returns the view of the controller on a zone. The records
+// are taken from the zone cache only, the provider is never accessed.
+func (this *state) getZoneState(zoneid string) *ZoneState {
+	this.lock.Lock()
+	if this.zones[zoneid] == nil {
+		this.lock.Unlock()
+		return nil
+	}
+	result := &ZoneState{ZoneInfo: *this.getZoneInfo(zoneid)}
+	entries := this.addEntriesForDomain(Entries{}, this.zones[zoneid].Domain())
+	names := []resources.ObjectName{}
+	for n := range this.zoneproviders[zoneid] {
+		names = append(names, n)
+	}
+	sort.Slice(names, func(i, j int) bool { return names[i].String() < names[j].String() })
+	var cache *zoneCache
+	for _, n := range names {
+		if p := this.providers[n]; p != nil && p.cache != nil {
+			cache = p.cache
+			break
+		}
+	}
+	this.lock.Unlock()
+
+	if cache != nil {
+		if sets, t, complete, ok := cache.cachedState(zoneid); ok {
+			result.CacheTime = &t
+			result.Complete = complete
+			result.Records = recordsInfo(sets)
+		}
+	}
+	for _, e := range entries {
+		if e.IsModified() {
+			info := &EntryInfo{Name: e.ObjectName().String(), DNSName: e.DNSName()}
+			for _, t := range e.Targets() {
+				info.Targets = append(info.Targets, t.GetHostName())
+			}
+			result.Pending = append(result.Pending, info)
+		}
+	}
+	sort.Slice(result.Pending, func(i, j int) bool { return result.Pending[i].Name < result.Pending[j].Name })
+	return result
+}
+
+func recordsInfo(sets dns.DNSSets) map[string]*RecordsInfo {
+	result := map[string]*RecordsInfo{}
+	for name, set := range sets {
+		info := &RecordsInfo{Owner: set.GetOwner(), Sets: map[string]*RecordSetInfo{}}
+		for ty, rs := range set.Sets {
+			rsinfo := &RecordSetInfo{TTL: rs.TTL, Records: []string{}}
+			for _, r := range rs.Records {
+				rsinfo.Records = append(rsinfo.Records, r.Value)
+			}
+			info.Sets[ty] = rsinfo
+		}
+		result[name] = info
+	}
+	return result
+}
@@ -0,0 +1,125 @@
+/*
+ * Copyright 2019 SAP SE or an SAP affiliate company. All rights reserved. This file is licensed under the Apache Software License, v. 2 except as noted otherwise in the LICENSE file
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ *
+ */
+
+package provider
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/gardener/controller-manager-library/pkg/resources"
+
+	api "github.com/gardener/external-dns-management/pkg/apis/dns/v1alpha1"
+
+	authorizationv1 "k8s.io/api/authorization/v1"
+)
+
+func init() {
+	resources.Register(authorizationv1.SchemeBuilder)
+}
+
+// VERB_USE is the RBAC verb required for the service accounts of a
+// namespace to reference a provider or an entry of another namespace.
+const VERB_USE = "use"
+
+// providerUsageTTL is the time the result of a SubjectAccessReview for an
+// object and namespace is reused.
+const providerUsageTTL = time.Minute
+
+type providerUsage struct {
+	allowed bool
+	time    time.Time
+}
+
+// providerUsages caches the results of the SubjectAccessReviews per
+// object and namespace, so that the api server is not asked for
+// every reconcilation of an entry.
+type providerUsages struct {
+	lock   sync.Mutex
+	usages map[string]*providerUsage
+}
+
+func newProviderUsages() *providerUsages {
+	return &providerUsages{usages: map[string]*providerUsage{}}
+}
+
+func (this *providerUsages) get(key string) (bool, bool) {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	u := this.usages[key]
+	if u == nil || time.Now().Sub(u.time) > providerUsageTTL {
+		delete(this.usages, key)
+		return false, false
+	}
+	return u.allowed, true
+}
+
+func (this *providerUsages) set(key string, allowed bool) {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	this.usages[key] = &providerUsage{allowed: allowed, time: time.Now()}
+}
+
+// checkProviderUsage checks by a SubjectAccessReview whether the service
+// accounts of the given namespace are granted the verb use for the provider.
+func (this *state) checkProviderUsage(p DNSProvider, namespace string) error {
+	return this.checkUsage(api.DNSProviderPlural, p.ObjectName(), namespace)
+}
+
+// checkUsage checks by a SubjectAccessReview whether the service accounts
+// of the given namespace are granted the verb use for an object of the
+// given resource. The results are cached for providerUsageTTL.
+func (this *state) checkUsage(resource string, name resources.ObjectName, namespace string) error {
+	key := resource + "|" + name.String() + "|" + namespace
+	allowed, ok := this.usages.get(key)
+	if !ok {
+		var err error
+		allowed, err = this.reviewUsage(resource, name, namespace)
+		if err != nil {
+			return err
+		}
+		this.usages.set(key, allowed)
+	}
+	if !allowed {
+		return fmt.Errorf("namespace %q is not granted to %s %s %s", namespace, VERB_USE, resource, name)
+	}
+	return nil
+}
+
+func (this *state) reviewUsage(resource string, name resources.ObjectName, namespace string) (bool, error) {
+	review := &authorizationv1.SubjectAccessReview{
+		Spec: authorizationv1.SubjectAccessReviewSpec{
+			Groups: []string{"system:serviceaccounts:" + namespace},
+			ResourceAttributes: &authorizationv1.ResourceAttributes{
+				Namespace: name.Namespace(),
+				Verb:      VERB_USE,
+				Group:     api.GroupName,
+				Resource:  resource,
+				Name:      name.Name(),
+			},
+		},
+	}
+	res, err := this.controller.GetMainCluster().Resources().GetByExample(review)
+	if err != nil {
+		return false, err
+	}
+	o, err := res.Create(review)
+	if err != nil {
+		return false, fmt.Errorf("cannot check access to %s %s: %s", resource, name, err)
+	}
+	return o.Data().(*authorizationv1.SubjectAccessReview).Status.Allowed, nil
+}
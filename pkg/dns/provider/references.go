@@ -0,0 +1,128 @@
+/*
+ * Copyright 2019 SAP SE or an SAP affiliate company. All rights reserved. This file is licensed under the Apache Software License, v. 2 except as noted otherwise in the LICENSE file
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ *
+ */
+
+package provider
+
+import (
+	"fmt"
+
+	"github.com/gardener/controller-manager-library/pkg/logger"
+	"github.com/gardener/controller-manager-library/pkg/resources"
+
+	api "github.com/gardener/external-dns-management/pkg/apis/dns/v1alpha1"
+	"github.com/gardener/external-dns-management/pkg/dns"
+	dnsutils "github.com/gardener/external-dns-management/pkg/dns/utils"
+)
+
+// setReferencedTargets sets the targets resolved for the target
+// references of the entry. They are used by the next validation.
+func (this *Entry) setReferencedTargets(refs []string) {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	this.refs = refs
+}
+
+// referencesEntry checks whether an entry refers to the given entry.
+func referencesEntry(object *dnsutils.DNSEntryObject, name resources.ObjectName) bool {
+	for _, ref := range object.DNSEntry().Spec.TargetRefs {
+		if targetRefName(object, ref.Namespace, ref.Name) == name {
+			return true
+		}
+	}
+	return false
+}
+
+func targetRefName(object *dnsutils.DNSEntryObject, namespace, name string) resources.ObjectName {
+	if namespace == "" {
+		namespace = object.GetNamespace()
+	}
+	return resources.NewObjectName(namespace, name)
+}
+
+// resolveTargetRefs returns the actual A, AAAA and CNAME targets of all entries
+// referenced by an entry. Entries of other namespaces may only be referenced
+// if the service accounts of the entry's namespace are granted to use them.
+func (this *state) resolveTargetRefs(object *dnsutils.DNSEntryObject) ([]string, error) {
+	refs := object.DNSEntry().Spec.TargetRefs
+	if len(refs) == 0 {
+		return nil, nil
+	}
+	for _, ref := range refs {
+		if ref.Namespace != "" && ref.Namespace != object.GetNamespace() {
+			name := resources.NewObjectName(ref.Namespace, ref.Name)
+			if err := this.checkUsage(api.DNSEntryPlural, name, object.GetNamespace()); err != nil {
+				return nil, fmt.Errorf("referenced entry %s: %s", name, err)
+			}
+		}
+	}
+	this.lock.Lock()
+	cyclic := this.referencesCycle(object, object.ObjectName(), resources.ObjectNameSet{})
+	this.lock.Unlock()
+	if cyclic {
+		return nil, fmt.Errorf("cyclic target references")
+	}
+	targets := []string{}
+	for _, ref := range refs {
+		name := targetRefName(object, ref.Namespace, ref.Name)
+		this.lock.Lock()
+		e := this.entries[name]
+		this.lock.Unlock()
+		if e == nil {
+			return nil, fmt.Errorf("referenced entry %s not found", name)
+		}
+		if !e.IsValid() {
+			return nil, fmt.Errorf("referenced entry %s is not valid", name)
+		}
+		for _, t := range e.Targets() {
+			switch t.GetRecordType() {
+			case dns.RS_A, dns.RS_AAAA, dns.RS_CNAME:
+				targets = append(targets, t.GetHostName())
+			}
+		}
+	}
+	return targets, nil
+}
+
+// referencesCycle checks whether the target references of an entry lead
+// back to the given entry, directly or via other entries. The state lock
+// must be held.
+func (this *state) referencesCycle(object *dnsutils.DNSEntryObject, start resources.ObjectName, visited resources.ObjectNameSet) bool {
+	for _, ref := range object.DNSEntry().Spec.TargetRefs {
+		name := targetRefName(object, ref.Namespace, ref.Name)
+		if name == start {
+			return true
+		}
+		if visited.Contains(name) {
+			continue
+		}
+		visited.Add(name)
+		if e := this.entries[name]; e != nil && this.referencesCycle(e.object, start, visited) {
+			return true
+		}
+	}
+	return false
+}
+
+// triggerReferencingEntries enqueues all entries referring to the given
+// entry. The state lock must be held.
+func (this *state) triggerReferencingEntries(logger logger.LogContext, name resources.ObjectName) {
+	for _, e := range this.entries {
+		if referencesEntry(e.object, name) {
+			logger.Infof("trigger referencing entry %s", e.ObjectName())
+			this.controller.Enqueue(e.object)
+		}
+	}
+}
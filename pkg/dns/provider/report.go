@@ -0,0 +1,190 @@
+/*
+ * Copyright 2019 SAP SE or an SAP affiliate company. All rights reserved. This file is licensed under the Apache Software License, v. 2 except as noted otherwise in the LICENSE file
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ *
+ */
+
+package provider
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/gardener/controller-manager-library/pkg/controllermanager/controller/reconcile"
+	"github.com/gardener/controller-manager-library/pkg/logger"
+	"github.com/gardener/controller-manager-library/pkg/resources"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/validation"
+
+	api "github.com/gardener/external-dns-management/pkg/apis/dns/v1alpha1"
+	"github.com/gardener/external-dns-management/pkg/dns/sharding"
+)
+
+// CMD_REPORT is the command used to update the DNSReport of a controller.
+const CMD_REPORT = "report"
+
+// staleThreshold is the time after which an entry not ready is
+// reported as stale.
+const staleThreshold = 10 * time.Minute
+
+// Report updates the DNSReport object summarizing the state of the
+// providers and entries of the controller.
+func (this *state) Report(logger logger.LogContext) reconcile.Status {
+	if this.config.ReportInterval <= 0 {
+		return reconcile.Succeeded(logger)
+	}
+	status := this.buildReport()
+	if err := this.writeReport(status); err != nil {
+		logger.Warnf("cannot update dns report: %s", err)
+	}
+	return reconcile.Succeeded(logger).RescheduleAfter(this.config.ReportInterval)
+}
+
+func (this *state) buildReport() *api.DNSReportStatus {
+	entrystates := map[resources.ObjectName]*api.DNSEntryStatus{}
+	if res, err := this.controller.GetMainCluster().Resources().GetByExample(&api.DNSEntry{}); err == nil {
+		if list, err := res.ListCached(labels.Everything()); err == nil {
+			for _, o := range list {
+				entrystates[o.ObjectName()] = &o.Data().(*api.DNSEntry).Status
+			}
+		}
+	}
+
+	now := time.Now()
+	status := &api.DNSReportStatus{
+		Identifier:     this.config.Ident,
+		ProviderType:   this.GetHandlerFactory().TypeCode(),
+		Healthy:        true,
+		Entries:        map[string]int{},
+		LastUpdateTime: metav1.NewTime(now),
+	}
+
+	this.lock.Lock()
+	defer this.lock.Unlock()
+
+	infos := map[resources.ObjectName]*api.DNSProviderInfo{}
+	for n, p := range this.providers {
+		info := &api.DNSProviderInfo{
+			Name:    n.String(),
+			State:   p.object.Status().State,
+			Entries: map[string]int{},
+		}
+		if info.State != api.STATE_READY {
+			status.Healthy = false
+		}
+		var last time.Time
+		for _, z := range p.GetZoneInfos() {
+			info.Zones = append(info.Zones, z.Id)
+			zone := this.zones[z.Id]
+			if zone == nil {
+				continue
+			}
+			t := zone.LastSync()
+			if t.IsZero() {
+				last = t
+				break
+			}
+			if last.IsZero() || t.Before(last) {
+				last = t
+			}
+		}
+		if !last.IsZero() {
+			info.LastSyncTime = &metav1.Time{Time: last}
+		}
+		sort.Strings(info.Zones)
+		infos[n] = info
+	}
+
+	for n, e := range this.entries {
+		s := entrystates[n]
+		if s == nil {
+			continue
+		}
+		state := s.State
+		if state == "" {
+			state = api.STATE_PENDING
+		}
+		stale := false
+		if state != api.STATE_READY {
+			status.Healthy = false
+			since := e.object.GetCreationTimestamp().Time
+			if s.LastSyncTime != nil {
+				since = s.LastSyncTime.Time
+			}
+			stale = now.Sub(since) > staleThreshold
+		}
+		status.Entries[state]++
+		if stale {
+			status.StaleEntries++
+		}
+		var p DNSProvider
+		if ref := e.object.GetProviderRef(); ref != nil {
+			if v := this.providers[ref]; v != nil {
+				p = v
+			}
+		} else {
+			p = this.lookupProvider(e.DNSName())
+		}
+		if p == nil || infos[p.ObjectName()] == nil {
+			continue
+		}
+		info := infos[p.ObjectName()]
+		info.Entries[state]++
+		if stale {
+			info.StaleEntries++
+		}
+	}
+
+	for _, info := range infos {
+		status.Providers = append(status.Providers, *info)
+	}
+	sort.Slice(status.Providers, func(i, j int) bool { return status.Providers[i].Name < status.Providers[j].Name })
+	return status
+}
+
+// ReportName returns the name of the DNSReport of a controller. It contains
+// the identifier, so that controllers with different identifiers do not
+// overwrite each other's report. Identifiers not usable as part of an
+// object name are replaced by their hash.
+func ReportName(controller, ident string, shards sharding.Sharding) string {
+	name := controller + "-" + ident
+	if len(validation.IsDNS1123Subdomain(shards.Name(name))) > 0 {
+		name = fmt.Sprintf("%s-%x", controller, sha256.Sum256([]byte(ident)))[:len(controller)+9]
+	}
+	return shards.Name(name)
+}
+
+func (this *state) writeReport(status *api.DNSReportStatus) error {
+	res, err := this.controller.GetMainCluster().Resources().GetByExample(&api.DNSReport{})
+	if err != nil {
+		return err
+	}
+	name := resources.NewObjectName(ReportName(this.controller.GetName(), this.config.Ident, this.config.Sharding))
+	obj, err := res.GetInto(name, &api.DNSReport{})
+	if err != nil {
+		if !errors.IsNotFound(err) {
+			return err
+		}
+		report := &api.DNSReport{}
+		report.Name = name.Name()
+		report.Status = *status
+		_, err = res.Create(report)
+		return err
+	}
+	obj.Data().(*api.DNSReport).Status = *status
+	return obj.Update()
+}
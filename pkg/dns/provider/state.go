@@ -20,10 +20,15 @@ import (
 	"fmt"
 	"github.com/gardener/controller-manager-library/pkg/controllermanager/controller/reconcile"
 	"github.com/gardener/controller-manager-library/pkg/utils"
+	"strconv"
 	"strings"
 	"sync"
+	"time"
 
 	api "github.com/gardener/external-dns-management/pkg/apis/dns/v1alpha1"
+	"github.com/gardener/external-dns-management/pkg/dns"
+	"github.com/gardener/external-dns-management/pkg/dns/metrics"
+	"github.com/gardener/external-dns-management/pkg/dns/tracing"
 	dnsutils "github.com/gardener/external-dns-management/pkg/dns/utils"
 
 	"github.com/gardener/controller-manager-library/pkg/controllermanager/controller"
@@ -42,6 +47,7 @@ type state struct {
 	config     Config
 
 	pending utils.StringSet
+	workers *zoneWorkers
 
 	owners          utils.StringSet
 	foreign         map[resources.ObjectName]*foreignProvider
@@ -55,6 +61,17 @@ type state struct {
 
 	entries  Entries
 	dnsnames map[string]*Entry
+	// providerentries are the valid entries assigned to a provider,
+	// entryproviders the provider assigned to an entry.
+	providerentries map[resources.ObjectName]resources.ObjectNameSet
+	entryproviders  map[resources.ObjectName]resources.ObjectName
+	// orphans are the dns names of deleted entries whose records must
+	// be kept in the provider, per zone id. Entries keep their finalizer
+	// until all zones of their domain have orphaned the records.
+	orphans map[string]utils.StringSet
+	// usages caches the checks for entries referencing providers
+	// of other namespaces.
+	usages *providerUsages
 
 	initialized bool
 }
@@ -63,13 +80,25 @@ var _ DNSState = &state{}
 
 func NewDNSState(controller controller.Interface, config Config) DNSState {
 	controller.Infof("using default ttl: %d", config.TTL)
+	if config.MinTTL > 0 || config.MaxTTL > 0 {
+		controller.Infof("ttl limits       : %d-%d", config.MinTTL, config.MaxTTL)
+	}
 	controller.Infof("using identifier : %s", config.Ident)
 	controller.Infof("dry run mode     : %t", config.Dryrun)
-	return &state{
+	controller.Infof("zone cache ttl   : %s", config.CacheTTL)
+	controller.Infof("coalesce interval: %s", config.CoalesceInterval)
+	controller.Infof("provider workers : %d", config.ProviderWorkers)
+	controller.Infof("sharding         : %s", config.Sharding)
+	controller.Infof("txt registry     : %s", dns.Registry)
+	if dns.ExternalDNSPrefix != "" {
+		controller.Infof("external prefix  : %s", dns.ExternalDNSPrefix)
+	}
+	s := &state{
 		controller:      controller,
 		config:          config,
 		owners:          utils.NewStringSet(config.Ident),
 		pending:         utils.StringSet{},
+		workers:         newZoneWorkers(controller.GetName()),
 		foreign:         map[resources.ObjectName]*foreignProvider{},
 		providers:       map[resources.ObjectName]*dnsProviderVersion{},
 		deleting:        map[resources.ObjectName]*dnsProviderVersion{},
@@ -80,7 +109,15 @@ func NewDNSState(controller controller.Interface, config Config) DNSState {
 		providersecrets: map[resources.ObjectName]resources.ObjectName{},
 		entries:         Entries{},
 		dnsnames:        map[string]*Entry{},
+		providerentries: map[resources.ObjectName]resources.ObjectNameSet{},
+		entryproviders:  map[resources.ObjectName]resources.ObjectName{},
+		orphans:         map[string]utils.StringSet{},
+		usages:          newProviderUsages(),
 	}
+	registerQueryState(s)
+	registerACMEState(s)
+	registerControllerReadiness(controller.GetName(), config.Factory.TypeCode(), config.ProviderReadiness)
+	return s
 }
 
 func (this *state) Setup() {
@@ -114,6 +151,13 @@ func (this *state) Start() {
 		this.controller.Infof("trigger %s", c)
 		this.controller.EnqueueCommand(c)
 	}
+	if this.config.ReportInterval > 0 {
+		this.controller.EnqueueCommand(CMD_REPORT)
+	}
+	if this.config.ProviderHealthInterval > 0 {
+		this.controller.EnqueueCommand(CMD_PROVIDER_HEALTH)
+	}
+	setControllerStarted(this.controller.GetName())
 }
 
 func (this *state) GetController() controller.Interface {
@@ -186,20 +230,82 @@ func (this *state) removeProviderForZone(zoneid string, p resources.ObjectName)
 func (this *state) LookupProvider(dnsname string) DNSProvider {
 	this.lock.Lock()
 	defer this.lock.Unlock()
+	return this.lookupProvider(dnsname)
+}
 
+func (this *state) lookupProvider(dnsname string) DNSProvider {
 	var found DNSProvider
 	match := -1
 	for _, p := range this.providers {
 		n := p.Match(dnsname)
-		if n > 0 {
-			if match < n {
-				found = p
-			}
+		if n > 0 && preferProvider(n, p, match, found) {
+			found = p
+			match = n
 		}
 	}
 	return found
 }
 
+// GetEntryCountForProvider returns the number of valid entries
+// assigned to a provider.
+func (this *state) GetEntryCountForProvider(name resources.ObjectName) int {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	return len(this.providerentries[name])
+}
+
+// assignProvider records the provider handling a valid entry (nil if the
+// entry is invalid or not handled by any provider).
+func (this *state) assignProvider(entry resources.ObjectName, provider resources.ObjectName) {
+	if old, ok := this.entryproviders[entry]; ok {
+		if old == provider {
+			return
+		}
+		if set := this.providerentries[old]; set != nil {
+			set.Remove(entry)
+			if len(set) == 0 {
+				delete(this.providerentries, old)
+			}
+		}
+		delete(this.entryproviders, entry)
+	}
+	if provider != nil {
+		set := this.providerentries[provider]
+		if set == nil {
+			set = resources.ObjectNameSet{}
+			this.providerentries[provider] = set
+		}
+		set.Add(entry)
+		this.entryproviders[entry] = provider
+	}
+}
+
+// updateProviderUsage publishes the actual entry count for the given
+// providers.
+func (this *state) updateProviderUsage(logger logger.LogContext, providers DNSProviders) {
+	for n := range providers {
+		this.lock.Lock()
+		p := this.providers[n]
+		this.lock.Unlock()
+		if p != nil {
+			p.updateUsage(logger, this.GetEntryCountForProvider(n))
+		}
+	}
+}
+
+// updateStaleRecords publishes the stale record sets kept in a zone
+// in the status of the providers of the zone.
+func (this *state) updateStaleRecords(logger logger.LogContext, zoneid string, providers DNSProviders, stale map[resources.ObjectName]map[string]time.Time) {
+	for n := range providers {
+		this.lock.Lock()
+		p := this.providers[n]
+		this.lock.Unlock()
+		if p != nil {
+			p.updateStaleRecords(logger, zoneid, stale[n])
+		}
+	}
+}
+
 func (this *state) GetSecretUsage(name resources.ObjectName) []resources.Object {
 	this.lock.Lock()
 	defer this.lock.Unlock()
@@ -329,7 +435,7 @@ func (this *state) getZoneForName(hostname string) (string, int) {
 	for zoneid, zone := range this.zones {
 		name := zone.Domain()
 		if dnsutils.Match(hostname, name) {
-			if length < len(name) {
+			if length < len(name) || (length == len(name) && this.preferZone(zone, this.zones[found])) {
 				length = len(name)
 				found = zoneid
 			}
@@ -338,10 +444,51 @@ func (this *state) getZoneForName(hostname string) (string, int) {
 	return found, length
 }
 
+// preferZone decides between two zones for the same domain (split-horizon).
+// Public zones are preferred, otherwise the zone id is used to get a
+// deterministic result.
+func (this *state) preferZone(zone, found *dnsHostedZone) bool {
+	if found == nil {
+		return true
+	}
+	if zone.IsPrivate() != found.IsPrivate() {
+		return !zone.IsPrivate()
+	}
+	return zone.Id() < found.Id()
+}
+
+func (this *state) TriggerZonesForDomain(zoneid string) {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	this.triggerZonesForDomain(zoneid)
+}
+
+// triggerZonesForDomain triggers a zone and all other zones for the
+// same domain, which all maintain the entries of the domain.
+func (this *state) triggerZonesForDomain(zoneid string) {
+	this.triggerHostedZone(zoneid)
+	zone := this.zones[zoneid]
+	if zone == nil {
+		return
+	}
+	for id, z := range this.zones {
+		if id != zoneid && z.Domain() == zone.Domain() {
+			this.triggerHostedZone(id)
+		}
+	}
+}
+
 func (this *state) triggerHostedZone(name string) {
 	cmd := "hostedzone:" + name
+	this.workers.Enqueued(name)
 	if this.controller.IsReady() {
-		this.controller.EnqueueCommand(cmd)
+		if this.config.CoalesceInterval > 0 {
+			// the queue keeps a delayed command only once, so changes
+			// for the zone during the interval are handled together
+			this.controller.GetPool("dns").EnqueueCommandAfter(cmd, this.config.CoalesceInterval)
+		} else {
+			this.controller.EnqueueCommand(cmd)
+		}
 	} else {
 		this.pending.Add(cmd)
 	}
@@ -450,6 +597,17 @@ func (this *state) _UpdateLocalProvider(logger logger.LogContext, obj *dnsutils.
 			logger.Infof("    %s: %s", z.Id, z.Domain)
 		}
 	}
+	if last != nil && new.resync != "" && new.resync != last.resync {
+		logger.Infof("resync of hosted zones requested (%s)", new.resync)
+		obj.Eventf(corev1.EventTypeNormal, "resync", "resync of hosted zones requested (%s)", new.resync)
+		new.cache.Invalidate()
+		for _, z := range new.zoneinfos {
+			for _, p := range this.getProvidersForZone(z.Id) {
+				p.InvalidateZoneCache(z.Id)
+			}
+			this.triggerHostedZone(z.Id)
+		}
+	}
 	this.triggerEntries(logger, entries)
 	return status
 }
@@ -460,9 +618,11 @@ func (this *state) _UpdateForeignProvider(logger logger.LogContext, obj *dnsutil
 	this.lock.Lock()
 	defer this.lock.Unlock()
 
-	if this.providers[pname] != nil {
+	if this.providers[pname] != nil || this.deleting[pname] != nil {
 		logger.Infof("provider %q switched type to %q -> remove it", obj.ObjectName(), obj.DNSProvider().Spec.Type)
-		this.removeLocalProvider(logger, obj)
+		if status := this.removeLocalProvider(logger, obj); status.Interval > 0 {
+			return status
+		}
 	}
 
 	cur := this.foreign[pname]
@@ -506,6 +666,7 @@ func (this *state) ProviderDeleted(logger logger.LogContext, key resources.Objec
 	this.lock.Lock()
 	defer this.lock.Unlock()
 
+	removeProviderHealth(key.ObjectName())
 	return this.removeForeignProvider(logger, key.ObjectName())
 }
 
@@ -535,19 +696,40 @@ func (this *state) removeLocalProvider(logger logger.LogContext, obj *dnsutils.D
 		if cur.handler == nil {
 			panic(fmt.Sprintf("OOPS, no handler for %s", pname))
 		}
+		// with sharding the shard responsible for the provider cleans up
+		// all its exclusive zones and finally removes the finalizer.
+		responsible := this.config.Sharding.IsResponsibleFor(pname.String())
+		pending := false
 		entries := Entries{}
 		zones := this.providerzones[obj.ObjectName()]
 		for n, z := range zones {
 			if this.isProviderForZone(n, pname) {
 				this.addEntriesForDomain(entries, z.Domain())
 				providers := this.getProvidersForZone(n)
+				protected := cur.IsProtected()
+				for _, e := range this.addEntriesForDomain(Entries{}, z.Domain()) {
+					protected = protected || e.IsProtected()
+				}
 				if len(providers) == 1 {
 					// if this is the last provider for this zone
 					// it must be cleanuped before the provider is gone
-					logger.Infof("provider is exclusively handling zone %q -> cleanup", n)
-					err := this.reconcileZone(logger, n, Entries{}, providers)
-					if err != nil {
-						logger.Errorf("cannot cleanup zone %q: %s", n, err)
+					if responsible && protected {
+						logger.Infof("provider is exclusively handling zone %q -> keeping records (protected)", n)
+					} else if responsible && cur.DeletionPolicy() == api.DELETION_POLICY_ORPHAN {
+						if !z.RequestCleanup(true) {
+							// like for entries only the ownership records are removed
+							logger.Infof("provider is exclusively handling zone %q -> orphan records (deletion policy %s)", n, api.DELETION_POLICY_ORPHAN)
+							this.triggerHostedZone(n)
+							pending = true
+							continue
+						}
+					} else if responsible && !z.RequestCleanup(false) {
+						// the cleanup is done by the regular zone reconcilation,
+						// the provider is kept until it is finished.
+						logger.Infof("provider is exclusively handling zone %q -> cleanup", n)
+						this.triggerHostedZone(n)
+						pending = true
+						continue
 					}
 					delete(this.zones, n)
 				} else {
@@ -561,12 +743,22 @@ func (this *state) removeLocalProvider(logger logger.LogContext, obj *dnsutils.D
 		for _, e := range entries {
 			this.controller.Enqueue(e.object)
 		}
+		if pending {
+			return reconcile.Succeeded(logger).RescheduleAfter(10 * time.Second)
+		}
 		err := this.registerSecret(logger, nil, cur)
 		if err != nil {
 			return reconcile.Delay(logger, err)
 		}
 		delete(this.deleting, obj.ObjectName())
 		delete(this.providerzones, obj.ObjectName())
+		for n := range this.providerentries[pname] {
+			delete(this.entryproviders, n)
+		}
+		delete(this.providerentries, pname)
+		if !responsible {
+			return reconcile.Succeeded(logger)
+		}
 		return reconcile.DelayOnError(logger, this.controller.RemoveFinalizer(cur.Object()))
 	}
 	return reconcile.Succeeded(logger)
@@ -594,22 +786,47 @@ func (this *state) UpdateSecret(logger logger.LogContext, obj resources.Object)
 // entry handling
 
 func (this *state) UpdateEntry(logger logger.LogContext, object *dnsutils.DNSEntryObject) reconcile.Status {
+	span := tracing.StartSpan("entry.reconcile", nil, "entry", object.ObjectName().String(), "dnsname", object.GetDNSName())
+	status := this.updateEntry(logger, object, span)
+	span.End(status.Error)
+	return status
+}
+
+func (this *state) updateEntry(logger logger.LogContext, object *dnsutils.DNSEntryObject, span *tracing.Span) reconcile.Status {
 	logger.Infof("reconcile ENTRY")
 	old, new, err := this.AddEntry(logger, object)
+	this.lock.Lock()
+	this.removeOrphan(new.DNSName())
+	this.lock.Unlock()
+	quota := false
+
+	refs, rerr := this.resolveTargetRefs(object)
+	new.setReferencedTargets(refs)
+	before := new.Targets()
 
 	newzone, _ := this.GetZoneForName(new.DNSName())
+	provider, perr := this.providerForEntry(object)
+	if provider == nil && object.GetProviderRef() != nil {
+		// referenced provider is handled by another controller
+		newzone = ""
+	}
 	if old != nil {
 		oldzone, _ := this.GetZoneForName(old.DNSName())
 		if oldzone != "" && (err != nil || oldzone != newzone) {
 			logger.Infof("dns name changed -> trigger old zone %q", oldzone)
-			this.triggerHostedZone(oldzone)
+			this.TriggerZonesForDomain(oldzone)
 		} else {
 			logger.Infof("dns name changed to %q", new.DNSName())
 		}
 	}
 
 	if err == nil {
-		provider := this.LookupProvider(object.GetDNSName())
+		err = perr
+	}
+	if err == nil {
+		err = rerr
+	}
+	if err == nil {
 		if provider != nil {
 			owners := object.GetOwners()
 			if len(owners) > 0 {
@@ -634,26 +851,184 @@ func (this *state) UpdateEntry(logger logger.LogContext, object *dnsutils.DNSEnt
 					}
 				}
 			}
+			if err == nil && provider.MaxEntries() > 0 && !new.IsValid() {
+				if this.GetEntryCountForProvider(provider.ObjectName()) >= provider.MaxEntries() {
+					err = fmt.Errorf("entry quota of provider %s exceeded (%d entries)", provider.ObjectName(), provider.MaxEntries())
+					quota = true
+				}
+			}
 		} else {
 			if newzone != "" {
 				err = fmt.Errorf("no matching %s provider found", this.GetHandlerFactory().TypeCode())
 			}
 		}
 	}
+	if !this.isResponsibleForEntry(newzone, object) {
+		logger.Debugf("entry handled by other shard")
+		return reconcile.Succeeded(logger)
+	}
+	if ferr := this.updateProtection(logger, new, provider); ferr != nil {
+		return reconcile.Delay(logger, ferr)
+	}
 	status := new.Update(logger, object, this.GetHandlerFactory().TypeCode(), newzone, err)
+	this.lock.Lock()
+	if provider != nil && new.IsValid() {
+		this.assignProvider(new.ObjectName(), provider.ObjectName())
+	} else {
+		this.assignProvider(new.ObjectName(), nil)
+	}
+	this.lock.Unlock()
+	if new.Targets().DifferFrom(before) {
+		this.lock.Lock()
+		this.triggerReferencingEntries(logger, new.ObjectName())
+		this.lock.Unlock()
+	}
+	if quota {
+		// check again later for free capacity
+		return status.RescheduleAfter(time.Minute)
+	}
 
 	if status.IsSucceeded() && new.IsValid() {
 		if new.Interval() > 0 {
-			this.controller.Enqueue(object.Object)
+			// look up the hostname targets again after the interval
+			status = status.RescheduleAfter(time.Duration(new.Interval()) * time.Second)
 		}
 		if new.IsModified() && newzone != "" {
 			logger.Infof("trigger zone %q", newzone)
-			this.triggerHostedZone(newzone)
+			span.SetAttributes("zone", newzone)
+			new.setPending(traceID(span))
+			this.addZoneTraceLink(newzone, span.Context())
+			this.TriggerZonesForDomain(newzone)
+		}
+	}
+	if new.HealthCheckInterval() > 0 {
+		new.ProbeHealth(func() { this.controller.Enqueue(object.Object) })
+		status = status.RescheduleAfter(new.HealthCheckInterval())
+	}
+	if this.config.PropagationCheck && new.IsValid() {
+		if d := new.CheckPropagation(logger, &this.config); d > 0 {
+			status = status.RescheduleAfter(d)
 		}
 	}
 	return status
 }
 
+// providerForEntry determines the provider responsible for an entry.
+// The allowlists of the provider must accept the entry, an explicitly
+// referenced provider must additionally handle the dns name and grant
+// the usage to the namespace of the entry.
+func (this *state) providerForEntry(object *dnsutils.DNSEntryObject) (DNSProvider, error) {
+	ref := object.GetProviderRef()
+	if ref == nil {
+		p := this.LookupProvider(object.GetDNSName())
+		if p == nil {
+			return nil, nil
+		}
+		return p, this.checkAllowlists(p, object, false)
+	}
+	this.lock.Lock()
+	p := this.providers[ref]
+	this.lock.Unlock()
+	if p == nil {
+		return nil, nil
+	}
+	if err := this.checkAllowlists(p, object, true); err != nil {
+		return p, err
+	}
+	if p.Match(object.GetDNSName()) <= 0 {
+		return p, fmt.Errorf("dns name %q is not handled by provider %s", object.GetDNSName(), ref)
+	}
+	if object.GetNamespace() != ref.Namespace() {
+		return p, this.checkProviderUsage(p, object.GetNamespace())
+	}
+	return p, nil
+}
+
+// checkAllowlists checks whether the namespace of an entry is allowed
+// to use the provider for its dns name.
+func (this *state) checkAllowlists(p DNSProvider, object *dnsutils.DNSEntryObject, explicit bool) error {
+	prov := dnsutils.DNSProvider(p.Object())
+	if prov == nil {
+		return nil
+	}
+	var nslabels map[string]string
+	if prov.HasDomainAllowlists() && object.GetNamespace() != prov.GetNamespace() {
+		ns := &corev1.Namespace{}
+		res, err := this.controller.GetMainCluster().Resources().GetByExample(ns)
+		if err != nil {
+			return err
+		}
+		if _, err := res.GetInto(resources.NewObjectName(object.GetNamespace()), ns); err != nil {
+			return fmt.Errorf("cannot get namespace %q: %s", object.GetNamespace(), err)
+		}
+		nslabels = ns.Labels
+	}
+	return prov.AllowsEntry(object.GetNamespace(), nslabels, object.GetDNSName(), explicit)
+}
+
+// updateProtection keeps a finalizer on protected entries to block their
+// deletion until the protection is removed, and on entries orphaning their
+// records to keep them until all zones have orphaned the records.
+func (this *state) updateProtection(logger logger.LogContext, e *Entry, provider DNSProvider) error {
+	if e.IsProtected() || (provider != nil && provider.IsProtected()) {
+		if !this.controller.HasFinalizer(e.object) {
+			logger.Infof("entry is protected -> set finalizer")
+			return this.controller.SetFinalizer(e.object)
+		}
+		return nil
+	}
+	if this.deletionPolicy(e.object, provider) == api.DELETION_POLICY_ORPHAN {
+		// the records must be orphaned by all zones before the entry is gone
+		if !this.controller.HasFinalizer(e.object) {
+			logger.Infof("entry orphans its records -> set finalizer")
+			return this.controller.SetFinalizer(e.object)
+		}
+		return nil
+	}
+	if this.controller.HasFinalizer(e.object) {
+		logger.Infof("entry is not protected anymore -> remove finalizer")
+		return this.controller.RemoveFinalizer(e.object)
+	}
+	return nil
+}
+
+func (this *state) DeleteEntry(logger logger.LogContext, object *dnsutils.DNSEntryObject) reconcile.Status {
+	if !this.controller.HasFinalizer(object) {
+		return reconcile.Succeeded(logger)
+	}
+	this.lock.Lock()
+	zoneid, _ := this.getZoneForName(object.GetDNSName())
+	this.lock.Unlock()
+	if !this.isResponsibleForEntry(zoneid, object) {
+		logger.Debugf("entry handled by other shard")
+		return reconcile.Succeeded(logger)
+	}
+	provider, _ := this.providerForEntry(object)
+	if object.GetAnnotations()[PROTECTED_ANNOTATION] == "true" || (provider != nil && provider.IsProtected()) {
+		logger.Infof("entry is protected -> deletion blocked")
+		object.Eventf(corev1.EventTypeWarning, "protected", "deletion blocked until annotation %s is removed", PROTECTED_ANNOTATION)
+		return reconcile.Succeeded(logger)
+	}
+	if this.deletionPolicy(object, provider) == api.DELETION_POLICY_ORPHAN {
+		if this.orphanEntry(logger, object) {
+			logger.Infof("waiting for zones to orphan the records")
+			return reconcile.Succeeded(logger).RescheduleAfter(10 * time.Second)
+		}
+	}
+	logger.Infof("entry is not protected anymore -> remove finalizer")
+	return reconcile.DelayOnError(logger, this.controller.RemoveFinalizer(object))
+}
+
+// isResponsibleForEntry checks whether the entry is handled by this shard.
+// Entries are handled by the shard of their zone, entries without zone
+// by the shard for the entry name.
+func (this *state) isResponsibleForEntry(zoneid string, object *dnsutils.DNSEntryObject) bool {
+	if zoneid != "" {
+		return this.config.Sharding.IsResponsibleFor(zoneid)
+	}
+	return this.config.Sharding.IsResponsibleFor(object.ObjectName().String())
+}
+
 func (this *state) EntryDeleted(logger logger.LogContext, key resources.ObjectKey) reconcile.Status {
 	this.lock.Lock()
 	defer this.lock.Unlock()
@@ -662,21 +1037,127 @@ func (this *state) EntryDeleted(logger logger.LogContext, key resources.ObjectKe
 	if old != nil {
 		zoneid, _ := this.getZoneForName(old.DNSName())
 		if zoneid != "" {
+			if this.deletionPolicy(old.object, this.lookupProvider(old.DNSName())) == api.DELETION_POLICY_ORPHAN {
+				logger.Infof("orphaning records for %q", old.DNSName())
+				this.addOrphan(zoneid, old.DNSName())
+			}
 			logger.Infof("removing entry %q (%s[%s])", key.ObjectName(), old.DNSName(), zoneid)
-			this.triggerHostedZone(zoneid)
+			this.triggerZonesForDomain(zoneid)
 		} else {
 			logger.Infof("removing foreign entry %q (%s)", key.ObjectName(), old.DNSName())
 		}
 		this.cleanupEntry(logger, old)
+		this.triggerReferencingEntries(logger, key.ObjectName())
 	} else {
 		logger.Infof("removing unknown entry %q", key.ObjectName())
 	}
 	return reconcile.Succeeded(logger)
 }
 
+// deletionPolicy returns the effective deletion policy for an entry
+// handled by the given provider.
+func (this *state) deletionPolicy(object *dnsutils.DNSEntryObject, provider DNSProvider) string {
+	policy := object.DNSEntry().Spec.DeletionPolicy
+	if policy != "" {
+		return policy
+	}
+	if provider != nil {
+		return provider.DeletionPolicy()
+	}
+	return api.DELETION_POLICY_DELETE
+}
+
+// addOrphan requests to keep the records of a dns name in all zones
+// of this shard for the domain of the given zone. The state lock must
+// be held.
+func (this *state) addOrphan(zoneid string, dnsname string) {
+	zone := this.zones[zoneid]
+	if zone == nil {
+		return
+	}
+	for id, z := range this.zones {
+		// zones of other shards are handled by their own shard
+		if z.Domain() == zone.Domain() && this.config.Sharding.IsResponsibleFor(id) {
+			set := this.orphans[id]
+			if set == nil {
+				set = utils.StringSet{}
+				this.orphans[id] = set
+			}
+			set.Add(dnsname)
+		}
+	}
+}
+
+// removeOrphan cancels the orphaning of a dns name. The state lock
+// must be held.
+func (this *state) removeOrphan(dnsname string) {
+	for id, set := range this.orphans {
+		set.Remove(dnsname)
+		if len(set) == 0 {
+			delete(this.orphans, id)
+		}
+	}
+}
+
+// isOrphanPending checks whether a zone has not yet orphaned the
+// records of a dns name. The state lock must be held.
+func (this *state) isOrphanPending(dnsname string) bool {
+	for id, set := range this.orphans {
+		if set.Contains(dnsname) && this.zones[id] != nil && this.config.Sharding.IsResponsibleFor(id) {
+			return true
+		}
+	}
+	return false
+}
+
+// takeOrphans returns the dns names to orphan in a zone.
+func (this *state) takeOrphans(zoneid string) utils.StringSet {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	return this.orphans[zoneid].Copy()
+}
+
+// orphansDone forgets the dns names orphaned by a zone.
+func (this *state) orphansDone(zoneid string, orphans utils.StringSet) {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	set := this.orphans[zoneid]
+	if set == nil {
+		return
+	}
+	for n := range orphans {
+		set.Remove(n)
+	}
+	if len(set) == 0 {
+		delete(this.orphans, zoneid)
+	}
+}
+
+// orphanEntry requests to keep the records of an entry to be deleted. The
+// entry is forgotten, so that its records are no longer maintained, and
+// all zones of its domain are triggered. It reports whether a zone has
+// not yet orphaned the records.
+func (this *state) orphanEntry(logger logger.LogContext, object *dnsutils.DNSEntryObject) bool {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+
+	if e := this.entries[object.ObjectName()]; e != nil {
+		zoneid, _ := this.getZoneForName(e.DNSName())
+		if zoneid != "" {
+			logger.Infof("orphaning records for %q", e.DNSName())
+			this.addOrphan(zoneid, e.DNSName())
+			this.triggerZonesForDomain(zoneid)
+		}
+		this.cleanupEntry(logger, e)
+		this.triggerReferencingEntries(logger, object.ObjectName())
+	}
+	return this.isOrphanPending(object.GetDNSName())
+}
+
 func (this *state) cleanupEntry(logger logger.LogContext, e *Entry) {
 	logger.Infof("cleanup old entry (duplicate=%t)", e.duplicate)
 	this.entries.Delete(e)
+	this.assignProvider(e.ObjectName(), nil)
 	if !e.duplicate {
 		var found *Entry
 		for _, a := range this.entries {
@@ -725,7 +1206,9 @@ func (this *state) AddEntry(logger logger.LogContext, object *dnsutils.DNSEntryO
 			if cur.ObjectName() != new.ObjectName() {
 				if cur.Before(new) {
 					new.duplicate = true
-					return old, new, fmt.Errorf("DNS name %q already busy for %q", dnsname, cur.ObjectName())
+					err := fmt.Errorf("DNS name %q already busy for %q", dnsname, cur.ObjectName())
+					object.Event(corev1.EventTypeWarning, "conflict", err.Error())
+					return old, new, err
 				} else {
 					cur.duplicate = true
 					logger.Warnf("DNS name %q already busy for %q, but this one was earlier", dnsname, cur.ObjectName())
@@ -753,22 +1236,138 @@ func (this *state) GetZoneInfo(zoneid string) (*dnsHostedZone, DNSProviders, Ent
 	return zone, this.getProvidersForZone(zoneid), this.addEntriesForDomain(Entries{}, zone.Domain())
 }
 
+// traceID returns the id of the trace of a span (empty if tracing is disabled).
+func traceID(span *tracing.Span) string {
+	if ctx := span.Context(); ctx.IsValid() {
+		return ctx.TraceID.String()
+	}
+	return ""
+}
+
+func (this *state) addZoneTraceLink(zoneid string, ctx tracing.SpanContext) {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+
+	zone := this.zones[zoneid]
+	if zone != nil {
+		zone.AddTraceLink(ctx)
+	}
+}
+
 func (this *state) ReconcileZone(logger logger.LogContext, zoneid string) reconcile.Status {
 	zone, providers, entries := this.GetZoneInfo(zoneid)
 	if zone == nil {
 		return reconcile.Failed(logger, fmt.Errorf("zone %s not used anymore -> stop reconciling", zoneid))
 	}
+	// the cleanup of a zone is requested by the shard responsible for
+	// its last provider.
+	if !this.config.Sharding.IsResponsibleFor(zoneid) && !zone.cleanupRequested() {
+		logger.Debugf("zone %q handled by other shard", zoneid)
+		return reconcile.Succeeded(logger)
+	}
+	this.workers.Dequeued(zoneid)
+	if d := zone.backoffRemaining(); d > 0 {
+		logger.Infof("zone %q (%s) in backoff for %s -> skip reconcilation", zoneid, zone.Domain(), d.Round(time.Second))
+		return reconcile.Succeeded(logger)
+	}
+	if !this.workers.Acquire(zoneid, providers) {
+		logger.Infof("no free worker for zone %q (%s) -> delay reconcilation", zoneid, zone.Domain())
+		return reconcile.Succeeded(logger).RescheduleAfter(5 * time.Second)
+	}
+	defer this.workers.Release(zoneid, providers)
 	if zone.TestAndSetBusy() {
 		logger.Infof("reconciling zone %q (%s) with %d entries entries", zoneid, zone.Domain(), len(entries))
 		defer zone.Release()
-		return reconcile.DelayOnError(logger, this.reconcileZone(logger, zoneid, entries, providers))
+		span := tracing.StartSpan("zone.reconcile", nil, "zone", zoneid, "domain", zone.Domain(), "entries", strconv.Itoa(len(entries)))
+		span.AddLink(zone.TakeTraceLinks()...)
+		orphans := this.takeOrphans(zoneid)
+		cleanup := zone.cleanupRequested()
+		if cleanup {
+			if zone.orphanRequested() {
+				logger.Infof("last provider for zone %q deleted -> orphan records", zoneid)
+			} else {
+				logger.Infof("last provider for zone %q deleted -> cleanup", zoneid)
+			}
+			entries = Entries{}
+		}
+		start := time.Now()
+		err := this.reconcileZone(logger, zone, entries, orphans, providers, span)
+		if err == nil {
+			this.orphansDone(zoneid, orphans)
+		}
+		metrics.ObserveZoneReconcile(this.GetHandlerFactory().TypeCode(), outcome(err), start, traceID(span))
+		span.End(err)
+		this.updateProviderUsage(logger, providers)
+		if err != nil {
+			d := zone.failed(this.config.Backoff)
+			metrics.AddZoneRetry(this.GetHandlerFactory().TypeCode(), zoneid, IsThrottlingError(err))
+			metrics.SetZoneBackoff(this.GetHandlerFactory().TypeCode(), zoneid, d)
+			logger.Warnf("reconcilation of zone %q failed: retry after %s", zoneid, d.Round(time.Second))
+			return reconcile.Failed(logger, err).RescheduleAfter(d)
+		}
+		zone.succeeded()
+		metrics.SetZoneBackoff(this.GetHandlerFactory().TypeCode(), zoneid, 0)
+		if cleanup {
+			zone.setCleanedUp()
+			for _, p := range providers {
+				this.controller.Enqueue(p.Object())
+			}
+			return reconcile.Succeeded(logger)
+		}
+		next := zoneSyncPeriod(providers)
+		if this.config.StaleRecords != STALE_RECORDS_REPORT && this.config.StaleRecordGracePeriod > 0 {
+			// reconcile again when the grace period of a kept stale record set is over
+			if d := zone.staleRecordsRemaining(this.config.StaleRecordGracePeriod); d > 0 && (next == 0 || d < next) {
+				next = d
+			}
+		}
+		if this.config.BackupInterval > 0 {
+			this.backupZone(logger, zone, providers)
+			if next == 0 || this.config.BackupInterval < next {
+				next = this.config.BackupInterval
+			}
+		}
+		if next > 0 {
+			return reconcile.Succeeded(logger).RescheduleAfter(next)
+		}
+		return reconcile.Succeeded(logger)
 	}
 	logger.Infof("reconciling zone %q (%s) already busy and skipped", zoneid, zone.Domain())
 	return reconcile.Succeeded(logger)
 }
 
-func (this *state) reconcileZone(logger logger.LogContext, zoneid string, entries Entries, providers DNSProviders) error {
+// zoneSyncPeriod returns the shortest sync interval explicitly configured
+// for the providers of a zone (0 if none).
+func zoneSyncPeriod(providers DNSProviders) time.Duration {
+	var period time.Duration
+	for _, p := range providers {
+		if d := p.SyncPeriod(); d > 0 && (period == 0 || d < period) {
+			period = d
+		}
+	}
+	return period
+}
+
+func (this *state) reconcileZone(logger logger.LogContext, zone *dnsHostedZone, entries Entries, orphans utils.StringSet, providers DNSProviders, span *tracing.Span) error {
+	zoneid := zone.Id()
 	changes := NewChangeModel(logger, this.owners, this.config, zoneid, providers)
+	changes.span = span
+	changes.stale = zone.StaleRecords()
+	changes.filter = &ZoneFilter{Names: utils.StringSet{}, Owners: this.owners}
+	for _, e := range entries {
+		changes.filter.Names.Add(e.DNSName())
+		if ref := e.ProviderRef(); ref != nil {
+			changes.refs[e.DNSName()] = ref
+		}
+		if e.IsProtected() {
+			changes.protected.Add(e.DNSName())
+		}
+	}
+	if orphans != nil {
+		changes.orphans = orphans
+		changes.filter.Names.AddSet(orphans)
+	}
+	changes.orphanAll = zone.orphanRequested()
 	err := changes.Setup()
 	if err != nil {
 		return err
@@ -776,10 +1375,12 @@ func (this *state) reconcileZone(logger logger.LogContext, zoneid string, entrie
 	modified := false
 	for _, e := range entries {
 		// TODO: err handling
-		mod, _ := changes.Apply(e.DNSName(), NewStatusUpdate(logger, e), e.Targets()...)
+		mod, _ := changes.Apply(e.DNSName(), NewStatusUpdate(logger, e, this.GetHandlerFactory().TypeCode()), e.TargetsForZone(zone.IsPrivate())...)
 		modified = modified || mod
 	}
-	modified = modified || changes.Cleanup(logger)
+	modified = changes.Cleanup(logger) || modified
+	zone.setStaleRecords(changes.found)
+	this.updateStaleRecords(logger, zoneid, providers, changes.staleByProv)
 	if modified {
 		err = changes.Update(logger)
 	}
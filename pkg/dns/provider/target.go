@@ -50,6 +50,16 @@ func (this Targets) DifferFrom(targets Targets) bool {
 	return false
 }
 
+// TTL returns the time-to-live requested by the entry of the targets.
+func (this Targets) TTL() *int64 {
+	for _, t := range this {
+		if t.GetEntry() != nil {
+			return t.GetEntry().TTL()
+		}
+	}
+	return nil
+}
+
 type Target interface {
 	GetHostName() string
 	GetRecordType() string
@@ -64,7 +74,7 @@ type target struct {
 }
 
 func NewText(t string, entry *Entry) Target {
-	return NewTarget(dns.RS_TXT, fmt.Sprintf("%q", t), entry)
+	return NewTarget(dns.RS_TXT, dns.QuoteText(t), entry)
 }
 
 func NewTarget(ty string, ta string, entry *Entry) Target {
@@ -76,8 +86,16 @@ func NewTargetFromEntry(name string, entry *Entry) Target {
 	if ip == nil {
 		return NewTarget(dns.RS_CNAME, name, entry)
 	} else {
-		return NewTarget(dns.RS_A, name, entry)
+		return NewTarget(addressRecordType(ip), name, entry)
+	}
+}
+
+// addressRecordType returns the record type for an IPv4 or IPv6 address.
+func addressRecordType(ip net.IP) string {
+	if ip.To4() == nil {
+		return dns.RS_AAAA
 	}
+	return dns.RS_A
 }
 
 func (t *target) GetEntry() *Entry      { return t.entry }
@@ -0,0 +1,71 @@
+/*
+ * Copyright 2019 SAP SE or an SAP affiliate company. All rights reserved. This file is licensed under the Apache Software License, v. 2 except as noted otherwise in the LICENSE file
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ *
+ */
+
+package provider
+
+import (
+	"fmt"
+
+	api "github.com/gardener/external-dns-management/pkg/apis/dns/v1alpha1"
+)
+
+// TTLLimits describes the time-to-live settings used for the records
+// maintained by a provider. A zero Min or Max means no limit.
+type TTLLimits struct {
+	Default int64
+	Min     int64
+	Max     int64
+}
+
+func newTTLLimits(config *Config, spec *api.DNSProviderSpec) (TTLLimits, error) {
+	limits := TTLLimits{Default: config.TTL, Min: config.MinTTL, Max: config.MaxTTL}
+	if spec.DefaultTTL != nil {
+		limits.Default = *spec.DefaultTTL
+	}
+	if spec.MinTTL != nil {
+		limits.Min = *spec.MinTTL
+	}
+	if spec.MaxTTL != nil {
+		limits.Max = *spec.MaxTTL
+	}
+	if limits.Default <= 0 || limits.Min < 0 || limits.Max < 0 {
+		return limits, fmt.Errorf("ttl values must be positive")
+	}
+	if limits.Max > 0 && limits.Min > limits.Max {
+		return limits, fmt.Errorf("minimum ttl %d is greater than maximum ttl %d", limits.Min, limits.Max)
+	}
+	return limits, nil
+}
+
+// Effective returns the time-to-live to use for a requested value.
+// If the value has to be adjusted to the limits, a message describing
+// the adjustment is returned, also.
+func (this TTLLimits) Effective(ttl *int64) (int64, string) {
+	if ttl == nil || *ttl <= 0 {
+		return this.clamp(this.Default)
+	}
+	return this.clamp(*ttl)
+}
+
+func (this TTLLimits) clamp(ttl int64) (int64, string) {
+	if this.Min > 0 && ttl < this.Min {
+		return this.Min, fmt.Sprintf("ttl %d raised to provider minimum %d", ttl, this.Min)
+	}
+	if this.Max > 0 && ttl > this.Max {
+		return this.Max, fmt.Sprintf("ttl %d lowered to provider maximum %d", ttl, this.Max)
+	}
+	return ttl, ""
+}
@@ -0,0 +1,114 @@
+/*
+ * Copyright 2019 SAP SE or an SAP affiliate company. All rights reserved. This file is licensed under the Apache Software License, v. 2 except as noted otherwise in the LICENSE file
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ *
+ */
+
+package provider
+
+import (
+	"sync"
+
+	"github.com/gardener/controller-manager-library/pkg/resources"
+
+	"github.com/gardener/external-dns-management/pkg/dns/metrics"
+)
+
+// zoneWorkers limits the number of concurrent zone reconcilations per
+// provider. The overall number is given by the size of the worker pool
+// for zone reconcilations. A slow provider therefore can only occupy the
+// workers granted to it, while zones of other providers are still reconciled.
+type zoneWorkers struct {
+	lock       sync.Mutex
+	controller string
+	queued     map[string]bool
+	active     map[resources.ObjectName]int
+	waiting    map[resources.ObjectName]map[string]bool
+}
+
+func newZoneWorkers(controller string) *zoneWorkers {
+	return &zoneWorkers{
+		controller: controller,
+		queued:     map[string]bool{},
+		active:     map[resources.ObjectName]int{},
+		waiting:    map[resources.ObjectName]map[string]bool{},
+	}
+}
+
+// Enqueued marks a zone as triggered for reconcilation.
+func (this *zoneWorkers) Enqueued(zoneid string) {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	this.queued[zoneid] = true
+	metrics.SetZoneQueueDepth(this.controller, len(this.queued))
+}
+
+// Dequeued marks the start of a zone reconcilation.
+func (this *zoneWorkers) Dequeued(zoneid string) {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	delete(this.queued, zoneid)
+	metrics.SetZoneQueueDepth(this.controller, len(this.queued))
+}
+
+// IsQueued checks whether a zone is triggered for reconcilation.
+func (this *zoneWorkers) IsQueued(zoneid string) bool {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	return this.queued[zoneid]
+}
+
+// Acquire reserves a worker for all providers of a zone. If a provider has
+// no free worker, the zone is marked as waiting for it and false is returned.
+// In this case the reconcilation has to be retried later.
+func (this *zoneWorkers) Acquire(zoneid string, providers DNSProviders) bool {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	for n, p := range providers {
+		limit := p.ZoneWorkers()
+		if limit > 0 && this.active[n] >= limit {
+			w := this.waiting[n]
+			if w == nil {
+				w = map[string]bool{}
+				this.waiting[n] = w
+			}
+			w[zoneid] = true
+			this.update(n)
+			return false
+		}
+	}
+	for n := range providers {
+		this.active[n]++
+		if w := this.waiting[n]; w != nil {
+			delete(w, zoneid)
+		}
+		this.update(n)
+	}
+	return true
+}
+
+func (this *zoneWorkers) Release(zoneid string, providers DNSProviders) {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	for n := range providers {
+		this.active[n]--
+		if this.active[n] <= 0 {
+			delete(this.active, n)
+		}
+		this.update(n)
+	}
+}
+
+func (this *zoneWorkers) update(n resources.ObjectName) {
+	metrics.SetZoneReconciles(n.String(), this.active[n], len(this.waiting[n]))
+}
@@ -19,15 +19,35 @@ package provider
 import (
 	"fmt"
 	"sync"
+	"time"
+
+	"github.com/gardener/external-dns-management/pkg/dns/tracing"
 )
 
 type dnsHostedZones map[string]*dnsHostedZone
 
 type dnsHostedZone struct {
-	lock   sync.Mutex
-	busy   bool
-	id     string
-	domain string
+	lock    sync.Mutex
+	busy    bool
+	id      string
+	domain  string
+	private bool
+
+	tracelinks []tracing.SpanContext
+	lastBackup time.Time
+	lastSync   time.Time
+	failures   int
+	retryAt    time.Time
+	// stale are the times the stale record sets of the zone
+	// have been found first.
+	stale map[string]time.Time
+	// cleanup is requested if the last provider of the zone is deleted,
+	// the next reconcilation ignores all entries of the zone.
+	cleanup   bool
+	cleanedUp bool
+	// orphan keeps the record sets on cleanup, only the ownership
+	// records are removed.
+	orphan bool
 }
 
 func newDNSHostedZone(id, domain string) *dnsHostedZone {
@@ -66,8 +86,94 @@ func (this *dnsHostedZone) Domain() string {
 	return this.domain
 }
 
+func (this *dnsHostedZone) IsPrivate() bool {
+	return this.private
+}
+
+// AddTraceLink remembers the trace of a request for a zone reconcilation.
+func (this *dnsHostedZone) AddTraceLink(ctx tracing.SpanContext) {
+	if !ctx.IsValid() {
+		return
+	}
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	this.tracelinks = append(this.tracelinks, ctx)
+}
+
+// TakeTraceLinks returns and resets the traces of the pending requests.
+func (this *dnsHostedZone) TakeTraceLinks() []tracing.SpanContext {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	links := this.tracelinks
+	this.tracelinks = nil
+	return links
+}
+
 ////////////////////////////////////////////////////////////////////////////////
 
 func (this *dnsHostedZone) update(i *DNSHostedZoneInfo) {
 	this.domain = i.Domain
+	this.private = i.Private
+}
+
+// StaleRecords returns the stale record sets found by the last
+// reconcilation of the zone.
+func (this *dnsHostedZone) StaleRecords() map[string]time.Time {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	stale := map[string]time.Time{}
+	for n, t := range this.stale {
+		stale[n] = t
+	}
+	return stale
+}
+
+func (this *dnsHostedZone) setStaleRecords(stale map[string]time.Time) {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	this.stale = stale
+}
+
+// staleRecordsRemaining returns the time until the grace period of the
+// next stale record set is over (0 if none is pending).
+func (this *dnsHostedZone) staleRecordsRemaining(grace time.Duration) time.Duration {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	var next time.Duration
+	now := time.Now()
+	for _, since := range this.stale {
+		if d := since.Add(grace).Sub(now); d > 0 && (next == 0 || d < next) {
+			next = d
+		}
+	}
+	return next
+}
+
+// RequestCleanup requests the removal of all record sets of the controller
+// by the next zone reconcilation. With orphan only the ownership records
+// are removed. It reports whether the cleanup is done.
+func (this *dnsHostedZone) RequestCleanup(orphan bool) bool {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	this.cleanup = true
+	this.orphan = orphan
+	return this.cleanedUp
+}
+
+func (this *dnsHostedZone) cleanupRequested() bool {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	return this.cleanup
+}
+
+func (this *dnsHostedZone) orphanRequested() bool {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	return this.cleanup && this.orphan
+}
+
+func (this *dnsHostedZone) setCleanedUp() {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	this.cleanedUp = true
 }
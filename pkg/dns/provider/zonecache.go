@@ -0,0 +1,412 @@
+/*
+ * Copyright 2019 SAP SE or an SAP affiliate company. All rights reserved. This file is licensed under the Apache Software License, v. 2 except as noted otherwise in the LICENSE file
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ *
+ */
+
+package provider
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/gardener/controller-manager-library/pkg/logger"
+	"github.com/gardener/controller-manager-library/pkg/utils"
+
+	"github.com/gardener/external-dns-management/pkg/dns"
+)
+
+// DNSZoneChange describes a modification of a hosted zone reported by
+// an IncrementalDNSHandler. Deletions contain the record set types
+// removed for a dns name, additions the new content of record sets.
+type DNSZoneChange struct {
+	Deletions dns.DNSSets
+	Additions dns.DNSSets
+}
+
+// IncrementalDNSHandler is an optional interface for handlers able to
+// report the modifications of a hosted zone since a former state, so that
+// the record sets of the zone need not be listed completely for every
+// zone reconcilation.
+type IncrementalDNSHandler interface {
+	// GetZoneStateToken returns a token describing the actual state of the zone.
+	GetZoneStateToken(zoneid string) (string, error)
+	// GetZoneChanges returns the modifications since the given state
+	// in chronological order together with the token for the new state.
+	GetZoneChanges(zoneid string, token string) ([]*DNSZoneChange, string, error)
+}
+
+// StreamingDNSHandler is an optional interface for handlers able to pass
+// the record sets of a zone page by page. It allows to keep only the
+// record sets relevant for the controller instead of the complete zone.
+type StreamingDNSHandler interface {
+	// VisitDNSSets calls the visitor for all record sets of a zone with the
+	// dns name used by the provider.
+	VisitDNSSets(zoneid string, visitor func(dnsname string, rs *dns.RecordSet)) error
+	// GetDNSSetsForName returns the record sets for a single dns name
+	// including its meta data.
+	GetDNSSetsForName(zoneid string, dnsname string) (dns.DNSSets, error)
+}
+
+// ZoneFilter describes the record sets of a zone relevant for a zone
+// reconcilation: the record sets for the dns names of the entries, the
+// meta data of all names and the record sets owned by the given owners.
+type ZoneFilter struct {
+	Names  utils.StringSet
+	Owners utils.StringSet
+}
+
+// maxNameLookups is the maximum number of dns names looked up separately
+// to complete a cached zone state. If more names are required, the zone
+// is read again.
+const maxNameLookups = 50
+
+////////////////////////////////////////////////////////////////////////////////
+
+type zoneState struct {
+	sets dns.DNSSets
+	// names are the dns names with complete record sets. If nil
+	// the state contains the complete zone.
+	names utils.StringSet
+	token string
+	time  time.Time
+}
+
+// zoneEntry holds the cached state of a single zone. Its lock serializes
+// the provider accesses for the zone, the published state is never
+// modified but replaced as a whole.
+type zoneEntry struct {
+	lock  sync.Mutex
+	state *zoneState
+}
+
+// zoneCache keeps the record sets of the hosted zones served by a handler.
+// The cached state is updated by the changes executed by this controller
+// and, if supported by the handler, incrementally by the changes reported
+// by the provider. It is completely refreshed after the cache ttl.
+// The cache lock only guards the zone map and the published states,
+// the provider is accessed under the lock of the zone entry.
+type zoneCache struct {
+	lock    sync.Mutex
+	handler DNSHandler
+	ttl     time.Duration
+	zones   map[string]*zoneEntry
+}
+
+func newZoneCache(handler DNSHandler, ttl time.Duration) *zoneCache {
+	return &zoneCache{handler: handler, ttl: ttl, zones: map[string]*zoneEntry{}}
+}
+
+func (this *zoneCache) getEntry(zoneid string) *zoneEntry {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	e := this.zones[zoneid]
+	if e == nil {
+		e = &zoneEntry{}
+		this.zones[zoneid] = e
+	}
+	return e
+}
+
+func (this *zoneCache) getState(e *zoneEntry) *zoneState {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	return e.state
+}
+
+func (this *zoneCache) setState(e *zoneEntry, state *zoneState) {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	e.state = state
+}
+
+// GetDNSSets returns the actual state of a zone. It contains at least the
+// record sets selected by the filter. The returned sets are a copy of the
+// cached state and may be modified by the caller. The second result
+// indicates whether the state has been served from the cache.
+func (this *zoneCache) GetDNSSets(zoneid string, filter *ZoneFilter) (dns.DNSSets, bool, error) {
+	if this.ttl <= 0 {
+		state, err := this.load(zoneid, filter)
+		if err != nil {
+			return nil, false, err
+		}
+		return state.sets, false, nil
+	}
+
+	e := this.getEntry(zoneid)
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	state := this.getState(e)
+	if state != nil && time.Now().Sub(state.time) < this.ttl {
+		state = state.clone()
+		err := this.update(zoneid, state)
+		if err == nil {
+			err = this.complete(zoneid, state, filter)
+		}
+		if err == nil {
+			this.setState(e, state)
+			return state.sets.Clone(), true, nil
+		}
+	}
+	state, err := this.load(zoneid, filter)
+	if err != nil {
+		this.setState(e, nil)
+		return nil, false, err
+	}
+	this.setState(e, state)
+	return state.sets.Clone(), false, nil
+}
+
+// Invalidate drops the cached state of all zones, so that they are
+// read completely by the next access.
+func (this *zoneCache) Invalidate() {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	for _, e := range this.zones {
+		e.state = nil
+	}
+}
+
+// InvalidateZone discards the cached state of a single zone.
+func (this *zoneCache) InvalidateZone(zoneid string) {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	if e := this.zones[zoneid]; e != nil {
+		e.state = nil
+	}
+}
+
+// cachedState returns a copy of the cached record sets of a zone without
+// accessing the provider. The third result indicates whether the state
+// contains the complete zone. It never waits for a pending provider access,
+// the published snapshot is copied outside of the cache lock.
+func (this *zoneCache) cachedState(zoneid string) (dns.DNSSets, time.Time, bool, bool) {
+	this.lock.Lock()
+	var state *zoneState
+	if e := this.zones[zoneid]; e != nil {
+		state = e.state
+	}
+	this.lock.Unlock()
+	if state == nil {
+		return nil, time.Time{}, false, false
+	}
+	return state.sets.Clone(), state.time, state.names == nil, true
+}
+
+func (this *zoneCache) load(zoneid string, filter *ZoneFilter) (*zoneState, error) {
+	state := &zoneState{time: time.Now()}
+	if h, ok := this.handler.(IncrementalDNSHandler); ok {
+		// changes done after retrieving the token are reapplied by the next
+		// update. This is harmless because the changes describe complete
+		// record sets.
+		token, err := h.GetZoneStateToken(zoneid)
+		if err != nil {
+			return nil, err
+		}
+		state.token = token
+	}
+	h, ok := this.handler.(StreamingDNSHandler)
+	if !ok || filter == nil {
+		sets, err := this.handler.GetDNSSets(zoneid)
+		if err != nil {
+			return nil, err
+		}
+		state.sets = sets
+		return state, nil
+	}
+
+	state.sets = dns.DNSSets{}
+	state.names = utils.StringSet{}
+	state.names.AddSet(filter.Names)
+	err := h.VisitDNSSets(zoneid, func(dnsname string, rs *dns.RecordSet) {
+		state.keep(dns.MapFromProvider(dns.NormalizeHostname(dnsname), rs))
+	})
+	if err != nil {
+		return nil, err
+	}
+	// record sets of owned names might have been skipped if they were
+	// passed before the meta data, so they are looked up again.
+	for name, set := range state.sets {
+		if !state.names.Contains(name) && set.IsOwnedBy(filter.Owners) {
+			if err := this.lookup(h, zoneid, state, name); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return state, nil
+}
+
+// complete looks up the record sets for the names required by the
+// filter, which are not yet contained in a cached state.
+func (this *zoneCache) complete(zoneid string, state *zoneState, filter *ZoneFilter) error {
+	if state.names == nil {
+		return nil
+	}
+	if filter == nil {
+		return fmt.Errorf("complete zone required")
+	}
+	missing := utils.StringSet{}
+	for name := range filter.Names {
+		if !state.names.Contains(name) {
+			missing.Add(name)
+		}
+	}
+	if len(missing) > maxNameLookups {
+		return fmt.Errorf("too many names missing")
+	}
+	h := this.handler.(StreamingDNSHandler)
+	for name := range missing {
+		if err := this.lookup(h, zoneid, state, name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (this *zoneCache) lookup(h StreamingDNSHandler, zoneid string, state *zoneState, name string) error {
+	sets, err := h.GetDNSSetsForName(zoneid, name)
+	if err != nil {
+		return err
+	}
+	if set := sets[name]; set != nil {
+		state.sets[name] = set
+	} else {
+		delete(state.sets, name)
+	}
+	state.names.Add(name)
+	return nil
+}
+
+func (this *zoneCache) update(zoneid string, state *zoneState) error {
+	h, ok := this.handler.(IncrementalDNSHandler)
+	if !ok {
+		return nil
+	}
+	changes, token, err := h.GetZoneChanges(zoneid, state.token)
+	if err != nil {
+		return err
+	}
+	for _, c := range changes {
+		for name, set := range c.Deletions {
+			for t := range set.Sets {
+				state.remove(name, t)
+			}
+		}
+		for name, set := range c.Additions {
+			for _, rs := range set.Sets {
+				state.keep(name, rs.Clone())
+			}
+		}
+	}
+	state.token = token
+	return nil
+}
+
+// Executed updates the cached state of a zone according to the
+// requests executed by the handler. If a request failed, the
+// zone state is discarded and completely read again for the next
+// reconcilation.
+func (this *zoneCache) Executed(logger logger.LogContext, zoneid string, reqs []*ChangeRequest, failed bool) {
+	this.lock.Lock()
+	e := this.zones[zoneid]
+	this.lock.Unlock()
+	if e == nil {
+		return
+	}
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	state := this.getState(e)
+	if state == nil {
+		return
+	}
+	if failed {
+		logger.Infof("discarding cached state for zone %s", zoneid)
+		this.setState(e, nil)
+		return
+	}
+	state = state.clone()
+	defer this.setState(e, state)
+	for _, r := range reqs {
+		switch r.Action {
+		case R_CREATE, R_UPDATE:
+			if rs := r.Addition.Sets[r.Type]; rs != nil {
+				state.add(r.Addition.Name, rs.Clone())
+			}
+		case R_DELETE:
+			state.remove(r.Deletion.Name, r.Type)
+		}
+	}
+}
+
+func (this *zoneState) clone() *zoneState {
+	state := &zoneState{sets: this.sets.Clone(), token: this.token, time: this.time}
+	if this.names != nil {
+		state.names = this.names.Copy()
+	}
+	return state
+}
+
+// keep adds a record set to the state if it is relevant. Meta data
+// is always kept, other record sets only for names with complete record sets
+// or known meta data.
+func (this *zoneState) keep(name string, rs *dns.RecordSet) {
+	if this.names == nil || rs.Type == dns.RS_META || this.names.Contains(name) || this.sets[name] != nil {
+		this.sets.AddRecordSet(name, rs)
+	}
+}
+
+func (this *zoneState) add(name string, rs *dns.RecordSet) {
+	this.sets.AddRecordSet(name, rs)
+}
+
+func (this *zoneState) remove(name string, rtype string) {
+	set := this.sets[name]
+	if set != nil {
+		delete(set.Sets, rtype)
+		if len(set.Sets) == 0 {
+			delete(this.sets, name)
+		}
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// executionResult tracks the outcome of the change requests passed
+// to a handler.
+type executionResult struct {
+	DoneHandler
+	failed *bool
+}
+
+func (this *executionResult) SetInvalid(err error) {
+	*this.failed = true
+	if this.DoneHandler != nil {
+		this.DoneHandler.SetInvalid(err)
+	}
+}
+
+func (this *executionResult) Failed(err error) {
+	*this.failed = true
+	if this.DoneHandler != nil {
+		this.DoneHandler.Failed(err)
+	}
+}
+
+func (this *executionResult) Succeeded() {
+	if this.DoneHandler != nil {
+		this.DoneHandler.Succeeded()
+	}
+}
@@ -25,6 +25,7 @@ const RS_META = "META"
 const RS_TXT = "TXT"
 const RS_CNAME = "CNAME"
 const RS_A = "A"
+const RS_AAAA = "AAAA"
 
 ////////////////////////////////////////////////////////////////////////////////
 // Record Sets
@@ -76,6 +77,9 @@ func (this *RecordSet) Add(records ...*Record) *RecordSet {
 }
 
 func (this *RecordSet) RecordString() string {
+	if this == nil {
+		return "no records"
+	}
 	line := ""
 	for _, r := range this.Records {
 		line = fmt.Sprintf("%s %s", line, r.Value)
@@ -105,6 +109,40 @@ func (this *RecordSet) Match(set *RecordSet) bool {
 	return true
 }
 
+// Diff describes the changes required to get from this record set
+// to the given one in a human readable form: added values are prefixed
+// by '+', removed ones by '-', followed by a changed time-to-live.
+// Both sets may be nil. The result is empty if there are no changes.
+func (this *RecordSet) Diff(set *RecordSet) string {
+	old := map[string]bool{}
+	changes := []string{}
+	if this != nil {
+		for _, r := range this.Records {
+			old[r.Value] = true
+		}
+	}
+	if set != nil {
+		for _, r := range set.Records {
+			if old[r.Value] {
+				delete(old, r.Value)
+			} else {
+				changes = append(changes, "+"+r.Value)
+			}
+		}
+	}
+	if this != nil {
+		for _, r := range this.Records {
+			if old[r.Value] {
+				changes = append(changes, "-"+r.Value)
+			}
+		}
+	}
+	if this != nil && set != nil && this.TTL != set.TTL {
+		changes = append(changes, fmt.Sprintf("ttl %d -> %d", this.TTL, set.TTL))
+	}
+	return strings.Join(changes, ", ")
+}
+
 func (this *RecordSet) GetAttr(name string) string {
 	if this.Type == RS_TXT || this.Type == RS_META {
 		prefix := newMetaKeyPrefix(name)
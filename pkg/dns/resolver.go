@@ -0,0 +1,193 @@
+/*
+ * Copyright 2019 SAP SE or an SAP affiliate company. All rights reserved. This file is licensed under the Apache Software License, v. 2 except as noted otherwise in the LICENSE file
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ *
+ */
+
+package dns
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/binary"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// NewResolver returns a resolver using a dedicated name server. The server
+// is given as
+//   - host[:port] for plain DNS (default port 53),
+//   - tls://host[:port] for DNS over TLS (default port 853) or
+//   - https://host[:port]/path for DNS over HTTPS (RFC 8484).
+func NewResolver(server string) (*net.Resolver, error) {
+	var dial func(ctx context.Context) (net.Conn, error)
+	switch {
+	case strings.HasPrefix(server, "https://"):
+		u, err := url.Parse(server)
+		if err != nil || u.Host == "" {
+			return nil, fmt.Errorf("invalid DNS over HTTPS url %q", server)
+		}
+		dial = func(ctx context.Context) (net.Conn, error) {
+			return &dohConn{ctx: ctx, url: server}, nil
+		}
+	case strings.HasPrefix(server, "tls://"):
+		host, addr := hostAndAddress(server[len("tls://"):], "853")
+		dial = func(ctx context.Context) (net.Conn, error) {
+			d := &net.Dialer{}
+			conn, err := d.DialContext(ctx, "tcp", addr)
+			if err != nil {
+				return nil, err
+			}
+			return tls.Client(conn, &tls.Config{ServerName: host}), nil
+		}
+	default:
+		_, addr := hostAndAddress(server, "53")
+		return &net.Resolver{
+			PreferGo: true,
+			Dial: func(ctx context.Context, network, address string) (net.Conn, error) {
+				d := net.Dialer{}
+				return d.DialContext(ctx, network, addr)
+			},
+		}, nil
+	}
+	// connections which are no packet connections are used by the go
+	// resolver like tcp connections
+	return &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, address string) (net.Conn, error) {
+			return dial(ctx)
+		},
+	}, nil
+}
+
+func hostAndAddress(server, port string) (string, string) {
+	host, _, err := net.SplitHostPort(server)
+	if err != nil {
+		return server, net.JoinHostPort(server, port)
+	}
+	return host, server
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// DNS over HTTPS
+
+var dohClient = &http.Client{Timeout: 10 * time.Second}
+
+// dohConn passes the messages written by the go resolver (in the tcp
+// format with a two byte length prefix) as DNS over HTTPS requests and
+// provides the answers for reading in the same format.
+type dohConn struct {
+	ctx      context.Context
+	url      string
+	deadline time.Time
+	request  []byte
+	response bytes.Buffer
+}
+
+func (this *dohConn) Write(b []byte) (int, error) {
+	this.request = append(this.request, b...)
+	for len(this.request) >= 2 {
+		n := int(binary.BigEndian.Uint16(this.request))
+		if len(this.request) < 2+n {
+			break
+		}
+		msg := this.request[2 : 2+n]
+		this.request = this.request[2+n:]
+		answer, err := this.query(msg)
+		if err != nil {
+			return 0, err
+		}
+		var l [2]byte
+		binary.BigEndian.PutUint16(l[:], uint16(len(answer)))
+		this.response.Write(l[:])
+		this.response.Write(answer)
+	}
+	return len(b), nil
+}
+
+func (this *dohConn) query(msg []byte) ([]byte, error) {
+	ctx := this.ctx
+	if !this.deadline.IsZero() {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithDeadline(ctx, this.deadline)
+		defer cancel()
+	}
+	req, err := http.NewRequest(http.MethodPost, this.url, bytes.NewReader(msg))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/dns-message")
+	req.Header.Set("Accept", "application/dns-message")
+	resp, err := dohClient.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("DNS over HTTPS request to %s failed: %s", this.url, resp.Status)
+	}
+	answer, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if len(answer) > 0xffff {
+		return nil, fmt.Errorf("DNS over HTTPS answer from %s too large", this.url)
+	}
+	return answer, nil
+}
+
+func (this *dohConn) Read(b []byte) (int, error) {
+	return this.response.Read(b)
+}
+
+func (this *dohConn) Close() error {
+	return nil
+}
+
+func (this *dohConn) LocalAddr() net.Addr {
+	return dohAddr("local")
+}
+
+func (this *dohConn) RemoteAddr() net.Addr {
+	return dohAddr(this.url)
+}
+
+func (this *dohConn) SetDeadline(t time.Time) error {
+	this.deadline = t
+	return nil
+}
+
+func (this *dohConn) SetReadDeadline(t time.Time) error {
+	return nil
+}
+
+func (this *dohConn) SetWriteDeadline(t time.Time) error {
+	this.deadline = t
+	return nil
+}
+
+type dohAddr string
+
+func (this dohAddr) Network() string {
+	return "https"
+}
+
+func (this dohAddr) String() string {
+	return string(this)
+}
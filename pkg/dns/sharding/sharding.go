@@ -0,0 +1,161 @@
+/*
+ * Copyright 2019 SAP SE or an SAP affiliate company. All rights reserved. This file is licensed under the Apache Software License, v. 2 except as noted otherwise in the LICENSE file
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ *
+ */
+
+// Package sharding splits the responsibility for hosted zones, entries and
+// providers among multiple replicas of the controller manager.
+package sharding
+
+import (
+	"fmt"
+	"hash/fnv"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/gardener/controller-manager-library/pkg/controllermanager/config"
+)
+
+const OPT_SHARDS = "shards"
+const OPT_SHARD = "shard"
+
+func init() {
+	config.RegisterExtension(func(cfg *config.Config) {
+		opt, _ := cfg.AddIntOption(OPT_SHARDS)
+		opt.Description = "number of shards the dns provisioning is split into (1 disables sharding)"
+		opt.Default = 1
+		opt, _ = cfg.AddIntOption(OPT_SHARD)
+		opt.Description = "shard handled by this controller manager (default: ordinal of the stateful set pod)"
+		opt.Default = -1
+	})
+}
+
+// Sharding describes the shard handled by a controller manager.
+type Sharding struct {
+	Shards int
+	Shard  int
+}
+
+// Get determines the sharding configured for a controller manager.
+func Get(cfg *config.Config) (Sharding, error) {
+	s := Sharding{Shards: 1}
+	if cfg == nil {
+		return s, nil
+	}
+	if o := cfg.GetOption(OPT_SHARDS); o != nil {
+		s.Shards = o.IntValue()
+	}
+	if o := cfg.GetOption(OPT_SHARD); o != nil {
+		s.Shard = o.IntValue()
+	}
+	return s.complete()
+}
+
+// FromArgs determines the sharding from the command line arguments.
+// It is used to determine the sharding before the command line is parsed.
+func FromArgs(args []string) (Sharding, error) {
+	s := Sharding{Shards: 1, Shard: -1}
+	for i := 0; i < len(args); i++ {
+		for _, n := range []string{OPT_SHARDS, OPT_SHARD} {
+			value := ""
+			switch {
+			case strings.HasPrefix(args[i], "--"+n+"="):
+				value = args[i][len(n)+3:]
+			case args[i] == "--"+n && i+1 < len(args):
+				i++
+				value = args[i]
+			default:
+				continue
+			}
+			v, err := strconv.Atoi(value)
+			if err != nil {
+				return s, fmt.Errorf("invalid value %q for option %s", value, n)
+			}
+			if n == OPT_SHARDS {
+				s.Shards = v
+			} else {
+				s.Shard = v
+			}
+			break
+		}
+	}
+	return s.complete()
+}
+
+func (this Sharding) complete() (Sharding, error) {
+	if this.Shards < 1 {
+		return this, fmt.Errorf("invalid number of shards %d", this.Shards)
+	}
+	if this.Shards == 1 {
+		return Sharding{Shards: 1}, nil
+	}
+	if this.Shard < 0 {
+		shard, err := ordinal()
+		if err != nil {
+			return this, err
+		}
+		this.Shard = shard
+	}
+	if this.Shard >= this.Shards {
+		return this, fmt.Errorf("shard %d out of range (%d shards)", this.Shard, this.Shards)
+	}
+	return this, nil
+}
+
+// ordinal determines the ordinal of a stateful set pod from the host name.
+func ordinal() (int, error) {
+	host, err := os.Hostname()
+	if err != nil {
+		return 0, err
+	}
+	i := strings.LastIndex(host, "-")
+	if i >= 0 {
+		if n, err := strconv.Atoi(host[i+1:]); err == nil {
+			return n, nil
+		}
+	}
+	return 0, fmt.Errorf("cannot determine shard from host name %q", host)
+}
+
+func (this Sharding) IsEnabled() bool {
+	return this.Shards > 1
+}
+
+// IsResponsibleFor checks whether the object with the given key, for example
+// a zone id or object name, is handled by this shard.
+func (this Sharding) IsResponsibleFor(key string) bool {
+	if this.Shards <= 1 {
+		return true
+	}
+	h := fnv.New32a()
+	h.Write([]byte(key))
+	return int(h.Sum32()%uint32(this.Shards)) == this.Shard
+}
+
+// Name returns the given name extended by the shard, if sharding is enabled.
+// It is used to get a dedicated leader election per shard.
+func (this Sharding) Name(name string) string {
+	if this.Shards <= 1 {
+		return name
+	}
+	return fmt.Sprintf("%s-shard-%d", name, this.Shard)
+}
+
+func (this Sharding) String() string {
+	if this.Shards <= 1 {
+		return "disabled"
+	}
+	return fmt.Sprintf("shard %d of %d", this.Shard, this.Shards)
+}
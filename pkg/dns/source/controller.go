@@ -34,6 +34,14 @@ const DNS_ANNOTATION = "dns.gardener.cloud/dnsnames"
 const KEY_ANNOTATION = "dns.gardener.cloud/key"
 const TTL_ANNOTATION = "dns.gardener.cloud/TTL"
 const PERIOD_ANNOTATION = "dns.gardener.cloud/cname-lookup-interval"
+const IP_STACK_ANNOTATION = "dns.gardener.cloud/ip-stack"
+const PROVIDER_ANNOTATION = "dns.gardener.cloud/provider"
+
+const (
+	IP_STACK_IPV4       = "ipv4"
+	IP_STACK_IPV6       = "ipv6"
+	IP_STACK_DUAL_STACK = "dual-stack"
+)
 
 const OPT_EXCLUDE = "exclude-domains"
 const OPT_KEY = "key"
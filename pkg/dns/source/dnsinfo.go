@@ -21,8 +21,11 @@ import (
 	"github.com/gardener/controller-manager-library/pkg/logger"
 	"github.com/gardener/controller-manager-library/pkg/resources"
 	"github.com/gardener/controller-manager-library/pkg/utils"
+	"net"
 	"strconv"
 	"strings"
+
+	api "github.com/gardener/external-dns-management/pkg/apis/dns/v1alpha1"
 )
 
 func (this *sourceReconciler) exclude(dns string) bool {
@@ -80,6 +83,28 @@ func (this *sourceReconciler) getDNSInfo(logger logger.LogContext, obj resources
 			}
 		}
 	}
+	if a := obj.GetAnnotations()[IP_STACK_ANNOTATION]; a != "" && info.Targets != nil {
+		switch a {
+		case IP_STACK_IPV4, IP_STACK_IPV6:
+			info.Targets = filterIPStack(info.Targets, a)
+		case IP_STACK_DUAL_STACK:
+		default:
+			return info, fmt.Errorf("invalid ip stack %q", a)
+		}
+	}
+	if info.Provider == nil {
+		if a := obj.GetAnnotations()[PROVIDER_ANNOTATION]; a != "" {
+			ref := &api.DNSProviderReference{Name: a}
+			if i := strings.Index(a, "/"); i >= 0 {
+				ref.Namespace = a[:i]
+				ref.Name = a[i+1:]
+			}
+			if ref.Name == "" || strings.Contains(ref.Name, "/") {
+				return info, fmt.Errorf("invalid provider %q (expected [<namespace>/]<name>)", a)
+			}
+			info.Provider = ref
+		}
+	}
 	if info.Interval == nil {
 		a := obj.GetAnnotations()[PERIOD_ANNOTATION]
 		if a != "" {
@@ -94,3 +119,16 @@ func (this *sourceReconciler) getDNSInfo(logger logger.LogContext, obj resources
 	}
 	return info, nil
 }
+
+// filterIPStack removes the addresses of the other ip family from
+// the targets. Host names are kept.
+func filterIPStack(targets utils.StringSet, stack string) utils.StringSet {
+	result := utils.StringSet{}
+	for t := range targets {
+		ip := net.ParseIP(t)
+		if ip == nil || (ip.To4() != nil) == (stack == IP_STACK_IPV4) {
+			result.Add(t)
+		}
+	}
+	return result
+}
@@ -23,6 +23,8 @@ import (
 	"github.com/gardener/controller-manager-library/pkg/resources"
 	"github.com/gardener/controller-manager-library/pkg/utils"
 
+	api "github.com/gardener/external-dns-management/pkg/apis/dns/v1alpha1"
+
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 )
@@ -33,6 +35,8 @@ type DNSInfo struct {
 	Interval *int64
 	Targets  utils.StringSet
 	Feedback DNSFeedback
+	// Provider explicitly selects the provider for the entries.
+	Provider *api.DNSProviderReference
 }
 
 type DNSFeedback interface {
@@ -19,9 +19,11 @@ package source
 import (
 	"fmt"
 	"k8s.io/apimachinery/pkg/api/errors"
+	"reflect"
 	"strings"
 	"time"
 
+	"github.com/gardener/controller-manager-library/pkg/controllermanager/config"
 	"github.com/gardener/controller-manager-library/pkg/controllermanager/controller"
 	"github.com/gardener/controller-manager-library/pkg/controllermanager/controller/reconcile"
 	"github.com/gardener/controller-manager-library/pkg/controllermanager/controller/reconcile/reconcilers"
@@ -31,6 +33,8 @@ import (
 
 	api "github.com/gardener/external-dns-management/pkg/apis/dns/v1alpha1"
 	"github.com/gardener/external-dns-management/pkg/dns"
+	"github.com/gardener/external-dns-management/pkg/dns/logging"
+	"github.com/gardener/external-dns-management/pkg/dns/sharding"
 	dnsutils "github.com/gardener/external-dns-management/pkg/dns/utils"
 
 	core "k8s.io/api/core/v1"
@@ -38,13 +42,19 @@ import (
 
 func SourceReconciler(sourceType DNSSourceType, rtype controller.ReconcilerType) controller.ReconcilerType {
 	return func(c controller.Interface) (reconcile.Interface, error) {
+		logging.Configure(c)
 		s, err := sourceType.Create(c)
 		if err != nil {
 			return nil, err
 		}
+		shards, err := sharding.Get(config.Get(c.GetContext()))
+		if err != nil {
+			return nil, err
+		}
 		reconciler := &sourceReconciler{
 			SlaveAccess: reconcilers.NewSlaveAccess(c, sourceType.Name(), SlaveResources, MasterResourcesType(sourceType.GroupKind())),
 			source:      s,
+			sharding:    shards,
 		}
 		nested, err := reconcilers.NewNestedReconciler(rtype, reconciler)
 		if err != nil {
@@ -60,6 +70,7 @@ type sourceReconciler struct {
 	*reconcilers.SlaveAccess
 	excluded   utils.StringSet
 	source     DNSSource
+	sharding   sharding.Sharding
 	key        string
 	namespace  string
 	nameprefix string
@@ -88,7 +99,19 @@ func (this *sourceReconciler) Setup() {
 	this.NestedReconciler.Setup()
 }
 
+// isResponsibleFor checks whether the source object is handled by this shard.
+func (this *sourceReconciler) isResponsibleFor(logger logger.LogContext, name resources.ObjectName) bool {
+	if this.sharding.IsResponsibleFor(name.String()) {
+		return true
+	}
+	logger.Debugf("source handled by other shard")
+	return false
+}
+
 func (this *sourceReconciler) Reconcile(logger logger.LogContext, obj resources.Object) reconcile.Status {
+	if !this.isResponsibleFor(logger, obj.ObjectName()) {
+		return reconcile.Succeeded(logger)
+	}
 	slaves := this.LookupSlaves(obj.ClusterKey())
 	names := utils.StringSet{}
 	for _, s := range slaves {
@@ -114,6 +137,13 @@ func (this *sourceReconciler) Reconcile(logger logger.LogContext, obj resources.
 		}
 		return reconcile.Succeeded(logger).Stop()
 	}
+	if err := this.checkProvider(obj, info); err != nil {
+		obj.Event(core.EventTypeWarning, "reconcile", err.Error())
+		if info.Feedback != nil {
+			info.Feedback.Failed("", err)
+		}
+		return reconcile.Failed(logger, err)
+	}
 	missing := utils.StringSet{}
 	obsolete := []resources.Object{}
 	obsolete_dns := utils.StringSet{}
@@ -269,6 +299,9 @@ outer:
 //  deleted unexpectedly (by removing the finalizer).
 //  It checks whether a slave is still available and deletes it.
 func (this *sourceReconciler) Deleted(logger logger.LogContext, key resources.ClusterObjectKey) reconcile.Status {
+	if !this.isResponsibleFor(logger, key.ObjectName()) {
+		return reconcile.Succeeded(logger)
+	}
 	logger.Infof("%s finally deleted", key)
 	failed := false
 	for _, s := range this.Slaves().GetByKey(key) {
@@ -289,6 +322,9 @@ func (this *sourceReconciler) Deleted(logger logger.LogContext, key resources.Cl
 }
 
 func (this *sourceReconciler) Delete(logger logger.LogContext, obj resources.Object) reconcile.Status {
+	if !this.isResponsibleFor(logger, obj.ObjectName()) {
+		return reconcile.Succeeded(logger)
+	}
 	failed := false
 	logger.Infof("entry source is deleting -> delete all dns entries")
 	for _, s := range this.Slaves().Get(obj) {
@@ -319,16 +355,58 @@ func (this *sourceReconciler) Delete(logger logger.LogContext, obj resources.Obj
 
 ////////////////////////////////////////////////////////////////////////////////
 
+// checkProvider checks whether the allowlists of an explicitly selected
+// provider accept the namespace of the source object. The generated
+// entries may be located in another namespace, which is checked by the
+// dns controller.
+func (this *sourceReconciler) checkProvider(obj resources.Object, info *DNSInfo) error {
+	if info.Provider == nil {
+		return nil
+	}
+	namespace := info.Provider.Namespace
+	if namespace == "" {
+		namespace = this.entryNamespace(obj)
+	}
+	prov, err := dnsutils.GetDNSProvider(this.GetCluster(TARGET_CLUSTER), namespace, info.Provider.Name)
+	if err != nil {
+		return fmt.Errorf("cannot get provider %s/%s: %s", namespace, info.Provider.Name, err)
+	}
+	var nslabels map[string]string
+	if prov.HasDomainAllowlists() {
+		ns := &core.Namespace{}
+		res, err := obj.GetCluster().Resources().GetByExample(ns)
+		if err != nil {
+			return err
+		}
+		if _, err := res.GetInto(resources.NewObjectName(obj.GetNamespace()), ns); err != nil {
+			return fmt.Errorf("cannot get namespace %q: %s", obj.GetNamespace(), err)
+		}
+		nslabels = ns.Labels
+	}
+	for n := range info.Names {
+		if err := prov.AllowsEntry(obj.GetNamespace(), nslabels, n, true); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// entryNamespace returns the namespace of the entries generated for
+// a source object.
+func (this *sourceReconciler) entryNamespace(obj resources.Object) string {
+	if this.namespace == "" {
+		return obj.GetNamespace()
+	}
+	return this.namespace
+}
+
 func (this *sourceReconciler) createEntryFor(logger logger.LogContext, obj resources.Object, dns string, info *DNSInfo) error {
 	entry := &api.DNSEntry{}
 	entry.GenerateName = strings.ToLower(this.nameprefix + obj.GetName() + "-" + obj.GroupKind().Kind + "-")
 	entry.Spec.DNSName = dns
 	entry.Spec.Targets = info.Targets.AsArray()
-	if this.namespace == "" {
-		entry.Namespace = obj.GetNamespace()
-	} else {
-		entry.Namespace = this.namespace
-	}
+	entry.Spec.ProviderRef = info.Provider
+	entry.Namespace = this.entryNamespace(obj)
 
 	e, _ := this.SlaveResoures()[0].Wrap(entry)
 
@@ -369,6 +447,10 @@ func (this *sourceReconciler) updateEntry(logger logger.LogContext, info *DNSInf
 		mod.AssureInt64PtrPtr(&spec.TTL, info.TTL)
 		mod.AssureInt64PtrPtr(&spec.CNameLookupInterval, info.Interval)
 		mod.AssureStringSet(&spec.Targets, info.Targets)
+		if !reflect.DeepEqual(spec.ProviderRef, info.Provider) {
+			spec.ProviderRef = info.Provider
+			mod.Modify(true)
+		}
 		if mod.IsModified() {
 			logger.Infof("update entry %s", obj.ObjectName())
 		}
@@ -0,0 +1,108 @@
+/*
+ * Copyright 2019 SAP SE or an SAP affiliate company. All rights reserved. This file is licensed under the Apache Software License, v. 2 except as noted otherwise in the LICENSE file
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ *
+ */
+
+package dns
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// MAX_TEXT_CHUNK is the maximum length of a single character string of
+// a TXT record.
+const MAX_TEXT_CHUNK = 255
+
+// QuoteText maps a text value to the record value of a TXT record.
+// Values longer than MAX_TEXT_CHUNK are split into multiple quoted
+// character strings separated by blanks. Splits are done only at
+// rune boundaries.
+func QuoteText(text string) string {
+	chunks := []string{}
+	for len(text) > MAX_TEXT_CHUNK {
+		n := MAX_TEXT_CHUNK
+		for n > 0 && !utf8.RuneStart(text[n]) {
+			n--
+		}
+		if n == 0 {
+			n = MAX_TEXT_CHUNK
+		}
+		chunks = append(chunks, fmt.Sprintf("%q", text[:n]))
+		text = text[n:]
+	}
+	chunks = append(chunks, fmt.Sprintf("%q", text))
+	return strings.Join(chunks, " ")
+}
+
+// SplitText returns the character strings of a TXT record value.
+// Unquoted character strings are accepted, too. The boolean result
+// indicates whether the value could be parsed.
+func SplitText(value string) ([]string, bool) {
+	chunks := []string{}
+	for {
+		value = strings.TrimLeft(value, " \t")
+		if value == "" {
+			return chunks, true
+		}
+		end := 0
+		if value[0] == '"' {
+			for end = 1; end < len(value) && value[end] != '"'; end++ {
+				if value[end] == '\\' {
+					end++
+				}
+			}
+			if end >= len(value) {
+				return nil, false
+			}
+			s, err := strconv.Unquote(value[:end+1])
+			if err != nil {
+				return nil, false
+			}
+			chunks = append(chunks, s)
+			end++
+		} else {
+			end = strings.IndexAny(value, " \t")
+			if end < 0 {
+				end = len(value)
+			}
+			chunks = append(chunks, value[:end])
+		}
+		value = value[end:]
+	}
+}
+
+// UnquoteText reassembles the text value of a TXT record value by
+// concatenating its character strings. If the value cannot be parsed,
+// it is returned unchanged.
+func UnquoteText(value string) string {
+	chunks, ok := SplitText(value)
+	if !ok {
+		return value
+	}
+	return strings.Join(chunks, "")
+}
+
+// NormalizeText maps a TXT record value read from a provider to the
+// representation generated by QuoteText, regardless of how the text has
+// been split into character strings.
+func NormalizeText(value string) string {
+	chunks, ok := SplitText(value)
+	if !ok {
+		return value
+	}
+	return QuoteText(strings.Join(chunks, ""))
+}
@@ -0,0 +1,214 @@
+/*
+ * Copyright 2019 SAP SE or an SAP affiliate company. All rights reserved. This file is licensed under the Apache Software License, v. 2 except as noted otherwise in the LICENSE file
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ *
+ */
+
+package tracing
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gardener/controller-manager-library/pkg/logger"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// OTLP/HTTP exporter using the JSON encoding of the OTLP trace protocol.
+// It is configured by the standard OpenTelemetry environment variables
+//   OTEL_EXPORTER_OTLP_TRACES_ENDPOINT  full url of the trace endpoint
+//   OTEL_EXPORTER_OTLP_ENDPOINT         base url (/v1/traces is appended)
+//   OTEL_SERVICE_NAME                   service name (dns-controller-manager)
+// If no endpoint is configured tracing is disabled.
+////////////////////////////////////////////////////////////////////////////////
+
+const (
+	batchSize     = 512
+	flushInterval = 5 * time.Second
+)
+
+type exporter struct {
+	lock     sync.Mutex
+	endpoint string
+	service  string
+	client   *http.Client
+	spans    []*Span
+}
+
+var defaultExporter *exporter
+
+func init() {
+	endpoint := os.Getenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT")
+	if endpoint == "" {
+		base := os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT")
+		if base != "" {
+			endpoint = strings.TrimSuffix(base, "/") + "/v1/traces"
+		}
+	}
+	if endpoint == "" {
+		return
+	}
+	service := os.Getenv("OTEL_SERVICE_NAME")
+	if service == "" {
+		service = "dns-controller-manager"
+	}
+	defaultExporter = &exporter{
+		endpoint: endpoint,
+		service:  service,
+		client:   &http.Client{Timeout: 10 * time.Second},
+	}
+	go defaultExporter.run()
+}
+
+// Enabled reports whether spans are recorded and exported.
+func Enabled() bool {
+	return defaultExporter != nil
+}
+
+func export(span *Span) {
+	if defaultExporter != nil {
+		defaultExporter.add(span)
+	}
+}
+
+func (this *exporter) add(span *Span) {
+	this.lock.Lock()
+	this.spans = append(this.spans, span)
+	flush := len(this.spans) >= batchSize
+	this.lock.Unlock()
+	if flush {
+		go this.flush()
+	}
+}
+
+func (this *exporter) run() {
+	logger.Infof("exporting traces to %s", this.endpoint)
+	for range time.Tick(flushInterval) {
+		this.flush()
+	}
+}
+
+func (this *exporter) flush() {
+	this.lock.Lock()
+	spans := this.spans
+	this.spans = nil
+	this.lock.Unlock()
+	if len(spans) == 0 {
+		return
+	}
+	if err := this.send(spans); err != nil {
+		logger.Warnf("cannot export %d spans: %s", len(spans), err)
+	}
+}
+
+func (this *exporter) send(spans []*Span) error {
+	data, err := json.Marshal(this.request(spans))
+	if err != nil {
+		return err
+	}
+	resp, err := this.client.Post(this.endpoint, "application/json", bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("trace endpoint returned %s", resp.Status)
+	}
+	return nil
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// OTLP JSON encoding
+
+type keyValue struct {
+	Key   string   `json:"key"`
+	Value anyValue `json:"value"`
+}
+
+type anyValue struct {
+	StringValue string `json:"stringValue"`
+}
+
+type otlpStatus struct {
+	Code    int    `json:"code"`
+	Message string `json:"message,omitempty"`
+}
+
+type otlpLink struct {
+	TraceID string `json:"traceId"`
+	SpanID  string `json:"spanId"`
+}
+
+type otlpSpan struct {
+	TraceID           string     `json:"traceId"`
+	SpanID            string     `json:"spanId"`
+	ParentSpanID      string     `json:"parentSpanId,omitempty"`
+	Name              string     `json:"name"`
+	Kind              int        `json:"kind"`
+	StartTimeUnixNano string     `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string     `json:"endTimeUnixNano"`
+	Attributes        []keyValue `json:"attributes,omitempty"`
+	Links             []otlpLink `json:"links,omitempty"`
+	Status            otlpStatus `json:"status"`
+}
+
+func (this *exporter) request(spans []*Span) interface{} {
+	list := make([]otlpSpan, 0, len(spans))
+	for _, s := range spans {
+		s.lock.Lock()
+		o := otlpSpan{
+			TraceID:           s.context.TraceID.String(),
+			SpanID:            s.context.SpanID.String(),
+			Name:              s.name,
+			Kind:              1, // internal
+			StartTimeUnixNano: fmt.Sprintf("%d", s.start.UnixNano()),
+			EndTimeUnixNano:   fmt.Sprintf("%d", s.end.UnixNano()),
+			Status:            otlpStatus{Code: 1},
+		}
+		if s.parent != (SpanID{}) {
+			o.ParentSpanID = s.parent.String()
+		}
+		for k, v := range s.attributes {
+			o.Attributes = append(o.Attributes, keyValue{k, anyValue{v}})
+		}
+		for _, l := range s.links {
+			o.Links = append(o.Links, otlpLink{l.TraceID.String(), l.SpanID.String()})
+		}
+		if s.err != nil {
+			o.Status = otlpStatus{Code: 2, Message: s.err.Error()}
+		}
+		s.lock.Unlock()
+		list = append(list, o)
+	}
+	return map[string]interface{}{
+		"resourceSpans": []interface{}{
+			map[string]interface{}{
+				"resource": map[string]interface{}{
+					"attributes": []keyValue{{"service.name", anyValue{this.service}}},
+				},
+				"scopeSpans": []interface{}{
+					map[string]interface{}{
+						"scope": map[string]string{"name": "github.com/gardener/external-dns-management"},
+						"spans": list,
+					},
+				},
+			},
+		},
+	}
+}
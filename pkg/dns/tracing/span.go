@@ -0,0 +1,130 @@
+/*
+ * Copyright 2019 SAP SE or an SAP affiliate company. All rights reserved. This file is licensed under the Apache Software License, v. 2 except as noted otherwise in the LICENSE file
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ *
+ */
+
+package tracing
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"sync"
+	"time"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// Spans are recorded only if an exporter is configured (see exporter.go).
+// All methods may be called on nil spans, so instrumented code does not
+// need to check whether tracing is enabled.
+////////////////////////////////////////////////////////////////////////////////
+
+type TraceID [16]byte
+type SpanID [8]byte
+
+func (this TraceID) String() string {
+	return hex.EncodeToString(this[:])
+}
+
+func (this SpanID) String() string {
+	return hex.EncodeToString(this[:])
+}
+
+type SpanContext struct {
+	TraceID TraceID
+	SpanID  SpanID
+}
+
+func (this SpanContext) IsValid() bool {
+	return this.TraceID != TraceID{}
+}
+
+type Span struct {
+	lock       sync.Mutex
+	name       string
+	context    SpanContext
+	parent     SpanID
+	start      time.Time
+	end        time.Time
+	attributes map[string]string
+	links      []SpanContext
+	err        error
+}
+
+// StartSpan starts a new span. If a parent is given the span belongs to the
+// trace of the parent, otherwise a new trace is started. Attributes are given
+// as key/value pairs.
+func StartSpan(name string, parent *Span, attrs ...string) *Span {
+	if !Enabled() {
+		return nil
+	}
+	span := &Span{
+		name:       name,
+		start:      time.Now(),
+		attributes: map[string]string{},
+	}
+	if parent != nil {
+		span.context.TraceID = parent.context.TraceID
+		span.parent = parent.context.SpanID
+	} else {
+		rand.Read(span.context.TraceID[:])
+	}
+	rand.Read(span.context.SpanID[:])
+	span.SetAttributes(attrs...)
+	return span
+}
+
+func (this *Span) Context() SpanContext {
+	if this == nil {
+		return SpanContext{}
+	}
+	return this.context
+}
+
+func (this *Span) SetAttributes(attrs ...string) {
+	if this == nil {
+		return
+	}
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	for i := 0; i+1 < len(attrs); i += 2 {
+		this.attributes[attrs[i]] = attrs[i+1]
+	}
+}
+
+// AddLink links the span to a span of another trace, for example a zone
+// reconcilation to the entry reconcilations that triggered it.
+func (this *Span) AddLink(links ...SpanContext) {
+	if this == nil {
+		return
+	}
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	for _, l := range links {
+		if l.IsValid() {
+			this.links = append(this.links, l)
+		}
+	}
+}
+
+// End finishes the span and hands it over to the exporter.
+func (this *Span) End(err error) {
+	if this == nil {
+		return
+	}
+	this.lock.Lock()
+	this.end = time.Now()
+	this.err = err
+	this.lock.Unlock()
+	export(this)
+}
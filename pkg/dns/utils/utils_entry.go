@@ -58,3 +58,17 @@ func (this *DNSEntryObject) GetTTL() *int64 {
 func (this *DNSEntryObject) GetCNameLookupInterval() *int64 {
 	return this.DNSEntry().Spec.CNameLookupInterval
 }
+
+// GetProviderRef returns the name of the explicitly referenced provider
+// or nil.
+func (this *DNSEntryObject) GetProviderRef() resources.ObjectName {
+	ref := this.DNSEntry().Spec.ProviderRef
+	if ref == nil {
+		return nil
+	}
+	namespace := ref.Namespace
+	if namespace == "" {
+		namespace = this.GetNamespace()
+	}
+	return resources.NewObjectName(namespace, ref.Name)
+}
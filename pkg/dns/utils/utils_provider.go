@@ -18,11 +18,15 @@ package utils
 
 import (
 	"fmt"
+	"strings"
+
 	"github.com/gardener/controller-manager-library/pkg/utils"
 
 	"github.com/gardener/controller-manager-library/pkg/resources"
 	api "github.com/gardener/external-dns-management/pkg/apis/dns/v1alpha1"
 
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 )
 
@@ -71,6 +75,86 @@ func (this *DNSProviderObject) SetDomains(included, excluded utils.StringSet) bo
 	return modified
 }
 
+// AllowsEntry checks the allowlists of the provider for an entry of the
+// given namespace (with the given namespace labels) and dns name. The
+// allowed namespaces decide whether the namespace may use the provider at
+// all, the domain allowlists which dns names it may use. An entry must be
+// accepted by both, entries of the provider's own namespace are always
+// accepted. The namespace labels are only required if the provider has
+// domain allowlists.
+func (this *DNSProviderObject) AllowsEntry(namespace string, nslabels map[string]string, dnsname string, explicit bool) error {
+	if namespace == this.GetNamespace() {
+		return nil
+	}
+	if !this.allowsNamespace(namespace, explicit) {
+		return fmt.Errorf("provider %s does not accept entries from namespace %q", this.ObjectName(), namespace)
+	}
+	ok, err := this.allowsDomain(namespace, nslabels, dnsname)
+	if err != nil {
+		return fmt.Errorf("provider %s: %s", this.ObjectName(), err)
+	}
+	if !ok {
+		return fmt.Errorf("provider %s does not allow dns name %q for namespace %q", this.ObjectName(), dnsname, namespace)
+	}
+	return nil
+}
+
+// HasDomainAllowlists reports whether the provider restricts the
+// domains usable by the namespaces of entries.
+func (this *DNSProviderObject) HasDomainAllowlists() bool {
+	return len(this.DNSProvider().Spec.DomainAllowlists) > 0
+}
+
+// allowsNamespace checks the allowed namespaces. Without allowed
+// namespaces entries of other namespaces may only select the provider
+// implicitly by domain.
+func (this *DNSProviderObject) allowsNamespace(namespace string, explicit bool) bool {
+	allowed := this.DNSProvider().Spec.AllowedNamespaces
+	if len(allowed) == 0 {
+		return !explicit
+	}
+	for _, n := range allowed {
+		if n == "*" || n == namespace {
+			return true
+		}
+	}
+	return false
+}
+
+// allowsDomain checks the domain allowlists. Without allowlists all
+// dns names of the provider are accepted.
+func (this *DNSProviderObject) allowsDomain(namespace string, nslabels map[string]string, dnsname string) (bool, error) {
+	if !this.HasDomainAllowlists() {
+		return true, nil
+	}
+	dnsname = strings.ToLower(dnsname)
+	for _, a := range this.DNSProvider().Spec.DomainAllowlists {
+		selected := false
+		for _, n := range a.Namespaces {
+			if n == namespace {
+				selected = true
+			}
+		}
+		if !selected && a.NamespaceSelector != nil {
+			sel, err := metav1.LabelSelectorAsSelector(a.NamespaceSelector)
+			if err != nil {
+				return false, fmt.Errorf("invalid namespace selector: %s", err)
+			}
+			selected = sel.Matches(labels.Set(nslabels))
+		}
+		if !selected {
+			continue
+		}
+		for _, d := range a.Domains {
+			d = strings.TrimPrefix(strings.ToLower(d), ".")
+			if dnsname == d || strings.HasSuffix(dnsname, "."+d) {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
 func DNSProvider(o resources.Object) *DNSProviderObject {
 	if o.IsA(DNSProviderType) {
 		return &DNSProviderObject{o}